@@ -0,0 +1,311 @@
+package lib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Codes used by the binder in field errors
+const (
+	FieldErrorCodeInvalid = "invalid"
+	FieldErrorCodeJSON    = "invalid_json"
+)
+
+const bindMaxMemory = 32 << 20
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	intType  = reflect.TypeOf(0)
+)
+
+// FieldErrors aggregates per-field errors. It implements the error interface
+type FieldErrors []FieldError
+
+// Error implements the error interface
+func (fe FieldErrors) Error() string {
+	messages := make([]string, 0, len(fe))
+	for _, f := range fe {
+		messages = append(messages, f.Field+": "+f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// HTTPError converts the field errors into a 422 validation HTTPError
+func (fe FieldErrors) HTTPError() *HTTPError {
+	return NewValidationError("invalid request", fe...)
+}
+
+// Binder fills structs from HTTP requests using the `query`, `form`, `path`
+// and `json` struct tags
+type Binder struct {
+	// PathParam returns the value of a path parameter. It plugs the router in
+	// use, e.g. gorilla/mux Vars or chi URLParam
+	PathParam func(r *http.Request, name string) string
+}
+
+// DefaultBinder is used by Bind
+var DefaultBinder = Binder{}
+
+// Bind fills dst from r using DefaultBinder
+func Bind(r *http.Request, dst interface{}) error {
+	return DefaultBinder.Bind(r, dst)
+}
+
+// Bind fills dst, a pointer to struct, from the JSON body, the form, the
+// query string and the path parameters of r, in this order of precedence
+// from lowest to highest. Conversion problems are returned as FieldErrors
+func (b Binder) Bind(r *http.Request, dst interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("Bind: destination must be a pointer to struct, got %T", dst)
+	}
+
+	var fieldErrors FieldErrors
+
+	if isJSONRequest(r) && r.Body != nil {
+		byteArray, buffer, err := GetByteArrayAndBufferFromRequestBody(r.Body)
+		if err != nil {
+			return errors.Wrap(err, "Bind: reading body")
+		}
+		// restore the body for the next handlers
+		r.Body = ioutil.NopCloser(buffer)
+		if len(byteArray) > 0 {
+			if err := json.Unmarshal(byteArray, dst); err != nil {
+				fieldErrors = append(fieldErrors, jsonFieldError(err))
+			}
+		}
+	}
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		if !isJSONRequest(r) {
+			if err := parseForm(r); err != nil {
+				return errors.Wrap(err, "Bind: parsing form")
+			}
+		}
+	}
+
+	sources := []struct {
+		tag    string
+		lookup func(name string) ([]string, bool)
+	}{
+		{"form", func(name string) ([]string, bool) {
+			v, ok := r.PostForm[name]
+			return v, ok
+		}},
+		{"query", func(name string) ([]string, bool) {
+			v, ok := r.URL.Query()[name]
+			return v, ok
+		}},
+		{"path", func(name string) ([]string, bool) {
+			if b.PathParam == nil {
+				return nil, false
+			}
+			v := b.PathParam(r, name)
+			return []string{v}, v != ""
+		}},
+	}
+
+	for _, source := range sources {
+		fieldErrors = append(fieldErrors, bindValues(value.Elem(), source.tag, source.lookup)...)
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return nil
+}
+
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func parseForm(r *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return r.ParseMultipartForm(bindMaxMemory)
+	}
+	return r.ParseForm()
+}
+
+func jsonFieldError(err error) FieldError {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+		return FieldError{
+			Field:   typeErr.Field,
+			Code:    FieldErrorCodeInvalid,
+			Message: "must be " + describeType(typeErr.Type),
+		}
+	}
+	return FieldError{Field: "body", Code: FieldErrorCodeJSON, Message: err.Error()}
+}
+
+func bindValues(structValue reflect.Value, tag string, lookup func(string) ([]string, bool)) (fieldErrors FieldErrors) {
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldValue := structValue.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fieldErrors = append(fieldErrors, bindValues(fieldValue, tag, lookup)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values, ok := lookup(name)
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		if err := setFieldFromStrings(fieldValue, values); err != nil {
+			fieldErrors = append(fieldErrors, FieldError{
+				Field:   name,
+				Code:    FieldErrorCodeInvalid,
+				Message: err.Error(),
+			})
+		}
+	}
+	return fieldErrors
+}
+
+func setFieldFromStrings(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setFieldFromStrings(elem.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice {
+		items := splitBindList(field.Type().Elem(), values)
+		if field.Type().Elem() == intType {
+			// StringToIntSlice skips invalid items, which are reported below
+			if ints := StringToIntSlice(strings.Join(items, ",")); len(ints) == len(items) {
+				field.Set(reflect.ValueOf(ints).Convert(field.Type()))
+				return nil
+			}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setFieldFromString(slice.Index(i), item); err != nil {
+				return errors.Errorf("item %d %s", i, err.Error())
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setFieldFromString(field, values[len(values)-1])
+}
+
+// splitBindList splits the comma separated values of a list. Lists of
+// strings and ints are split by StringToStringSlice, which keeps only letters
+// and digits, the others on commas so that signs, decimals and dates are kept
+func splitBindList(elemType reflect.Type, values []string) []string {
+	if elemType.Kind() == reflect.String || elemType == intType {
+		return StringToStringSlice(strings.Join(values, ","))
+	}
+	var items []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func setFieldFromString(field reflect.Value, s string) error {
+	if field.Type() == timeType {
+		date, err := ParseDateStringToTime(s)
+		if err != nil {
+			return errors.New("must be a date")
+		}
+		field.Set(reflect.ValueOf(*date))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := parseBoolString(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := ParseStringToInt64(s)
+		if err != nil || field.OverflowInt(i) {
+			return errors.New("must be " + describeType(field.Type()))
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := ParseStringToInt64(s)
+		if err != nil || i < 0 || field.OverflowUint(uint64(i)) {
+			return errors.New("must be " + describeType(field.Type()))
+		}
+		field.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := ParseStringToFloat64(s)
+		if err != nil || field.OverflowFloat(f) {
+			return errors.New("must be " + describeType(field.Type()))
+		}
+		field.SetFloat(f)
+	default:
+		return errors.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// parseBoolString accepts the lib boolean representation ("1" and "0") as
+// well as "true" and "false"
+func parseBoolString(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "0":
+		return ParseStringToBool(s), nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, errors.New("must be a boolean")
+}
+
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return "a " + t.String()
+}
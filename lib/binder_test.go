@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bindSearch struct {
+	HotelID  int64      `path:"hotelID"`
+	Page     int        `query:"page"`
+	Amenity  []int      `query:"amenity"`
+	Tags     []string   `query:"tags"`
+	Refund   bool       `query:"refundable"`
+	Checkin  time.Time  `query:"checkin"`
+	Checkout *time.Time `query:"checkout"`
+	Price    *float64   `query:"price"`
+	Name     string     `json:"name" form:"name"`
+	Adults   int        `json:"adults" form:"adults"`
+	ignored  string
+}
+
+func TestBindQueryAndPath(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet,
+		"http://server.com/hotels/42?page=2&amenity=1,2&amenity=3&tags=pool,%20spa&refundable=1&checkin=2020-10-22&checkout=2020-10-25T12:00:00Z&price=99.9", nil)
+
+	binder := Binder{PathParam: func(r *http.Request, name string) string {
+		return map[string]string{"hotelID": "42"}[name]
+	}}
+
+	var dst bindSearch
+	err := binder.Bind(req, &dst)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), dst.HotelID)
+	assert.Equal(t, 2, dst.Page)
+	assert.Equal(t, []int{1, 2, 3}, dst.Amenity)
+	assert.Equal(t, []string{"pool", "spa"}, dst.Tags)
+	assert.True(t, dst.Refund)
+	assert.Equal(t, time.Date(2020, 10, 22, 0, 0, 0, 0, time.UTC), dst.Checkin)
+	assert.Equal(t, time.Date(2020, 10, 25, 12, 0, 0, 0, time.UTC), dst.Checkout.UTC())
+	assert.Equal(t, 99.9, *dst.Price)
+}
+
+func TestBindJSONBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://server.com/hotels?page=3",
+		bytes.NewBufferString(`{"name":"Hotel São Paulo","adults":2}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var dst bindSearch
+	err := Bind(req, &dst)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Hotel São Paulo", dst.Name)
+	assert.Equal(t, 2, dst.Adults)
+	assert.Equal(t, 3, dst.Page)
+
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Hotel São Paulo","adults":2}`, string(body), "the body is restored")
+}
+
+func TestBindForm(t *testing.T) {
+	form := url.Values{"name": {"Copacabana Palace"}, "adults": {"3"}}
+	req, _ := http.NewRequest(http.MethodPost, "http://server.com/hotels", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst bindSearch
+	err := Bind(req, &dst)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Copacabana Palace", dst.Name)
+	assert.Equal(t, 3, dst.Adults)
+}
+
+func TestBindAggregatesFieldErrors(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost,
+		"http://server.com/hotels?page=abc&amenity=1,x&refundable=maybe&checkin=22/10/2020",
+		bytes.NewBufferString(`{"name":"ok","adults":"two"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var dst bindSearch
+	err := Bind(req, &dst)
+
+	fieldErrors, ok := err.(FieldErrors)
+	assert.True(t, ok)
+	assert.Equal(t, FieldErrors{
+		{Field: "adults", Code: FieldErrorCodeInvalid, Message: "must be an integer"},
+		{Field: "page", Code: FieldErrorCodeInvalid, Message: "must be an integer"},
+		{Field: "amenity", Code: FieldErrorCodeInvalid, Message: "item 1 must be an integer"},
+		{Field: "refundable", Code: FieldErrorCodeInvalid, Message: "must be a boolean"},
+		{Field: "checkin", Code: FieldErrorCodeInvalid, Message: "must be a date"},
+	}, fieldErrors)
+
+	httpErr := fieldErrors.HTTPError()
+	assert.Equal(t, http.StatusUnprocessableEntity, httpErr.Status)
+	assert.Len(t, httpErr.Fields, 5)
+}
+
+func TestBindInvalidJSON(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://server.com/hotels", bytes.NewBufferString(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var dst bindSearch
+	err := Bind(req, &dst)
+
+	fieldErrors, ok := err.(FieldErrors)
+	assert.True(t, ok)
+	assert.Equal(t, "body", fieldErrors[0].Field)
+	assert.Equal(t, FieldErrorCodeJSON, fieldErrors[0].Code)
+}
+
+func TestBindInvalidDestination(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://server.com", nil)
+
+	var dst bindSearch
+	assert.NotNil(t, Bind(req, dst))
+	assert.NotNil(t, Bind(req, nil))
+}
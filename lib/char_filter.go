@@ -0,0 +1,199 @@
+package lib
+
+import (
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharFilter keeps only the allowed characters of a text. It is built once
+// and is safe for concurrent use
+type CharFilter struct {
+	ascii  [utf8.RuneSelf]bool
+	ranges []charRange
+	tables []*unicode.RangeTable
+	runes  map[rune]struct{}
+}
+
+type charRange struct {
+	lo, hi rune
+}
+
+// CharFilterOption adds allowed characters to a CharFilter
+type CharFilterOption func(*CharFilter)
+
+// AllowRange allows every rune between lo and hi, both inclusive
+func AllowRange(lo, hi rune) CharFilterOption {
+	return func(f *CharFilter) {
+		f.ranges = append(f.ranges, charRange{lo: lo, hi: hi})
+	}
+}
+
+// AllowClass allows every rune of the given Unicode classes, e.g. unicode.L
+func AllowClass(tables ...*unicode.RangeTable) CharFilterOption {
+	return func(f *CharFilter) {
+		f.tables = append(f.tables, tables...)
+	}
+}
+
+// AllowRunes allows each rune of s
+func AllowRunes(s string) CharFilterOption {
+	return func(f *CharFilter) {
+		for _, r := range s {
+			f.runes[r] = struct{}{}
+		}
+	}
+}
+
+// NewCharFilter creates a CharFilter allowing the characters given by the
+// options:
+//
+//	phone := NewCharFilter(AllowRange('0', '9'), AllowRunes("+"))
+//	phone.Filter("+55 (21) 9999-8888") // "+552199998888"
+func NewCharFilter(options ...CharFilterOption) *CharFilter {
+	f := &CharFilter{runes: map[rune]struct{}{}}
+	for _, option := range options {
+		option(f)
+	}
+	for c := rune(0); c < utf8.RuneSelf; c++ {
+		f.ascii[c] = f.allowsSlow(c)
+	}
+	return f
+}
+
+// Allows reports whether r is kept by the filter
+func (f *CharFilter) Allows(r rune) bool {
+	if r >= 0 && r < utf8.RuneSelf {
+		return f.ascii[r]
+	}
+	return f.allowsSlow(r)
+}
+
+func (f *CharFilter) allowsSlow(r rune) bool {
+	if _, ok := f.runes[r]; ok {
+		return true
+	}
+	for _, rg := range f.ranges {
+		if r >= rg.lo && r <= rg.hi {
+			return true
+		}
+	}
+	for _, table := range f.tables {
+		if unicode.Is(table, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns s without the characters not allowed. Invalid UTF-8 bytes
+// are removed. When nothing is removed s is returned without allocation
+func (f *CharFilter) Filter(s string) string {
+	i := f.firstRejected(s)
+	if i == len(s) {
+		return s
+	}
+	buf := make([]byte, i, len(s))
+	copy(buf, s[:i])
+	return string(f.appendAllowed(buf, s[i:]))
+}
+
+// FilterPtr filters the string pointed by s. A nil pointer returns nil
+func (f *CharFilter) FilterPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	result := f.Filter(*s)
+	return &result
+}
+
+// FilterBytes returns a new slice with only the allowed characters of b
+func (f *CharFilter) FilterBytes(b []byte) []byte {
+	result := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		if c := b[0]; c < utf8.RuneSelf {
+			if f.ascii[c] {
+				result = append(result, c)
+			}
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if (r != utf8.RuneError || size > 1) && f.allowsSlow(r) {
+			result = append(result, b[:size]...)
+		}
+		b = b[size:]
+	}
+	return result
+}
+
+// firstRejected returns the index of the first byte to be removed from s,
+// or len(s) when s is kept as is
+func (f *CharFilter) firstRejected(s string) int {
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if !f.ascii[c] {
+				return i
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || !f.allowsSlow(r) {
+			return i
+		}
+		i += size
+	}
+	return len(s)
+}
+
+func (f *CharFilter) appendAllowed(buf []byte, s string) []byte {
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if f.ascii[c] {
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r != utf8.RuneError || size > 1) && f.allowsSlow(r) {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return buf
+}
+
+// maxDigitsOrSpecialFilters bounds the cache of digitsOrSpecialFilter. The
+// filters of other special characters are built on each call
+const maxDigitsOrSpecialFilters = 64
+
+var (
+	digitsFilter = NewCharFilter(AllowRange('0', '9'))
+
+	// digitsOrSpecialFilters caches the filters used by GetOnlyNumbersOrSpecial
+	// by their special characters, counted by digitsOrSpecialFiltersLen
+	digitsOrSpecialFilters    sync.Map
+	digitsOrSpecialFiltersLen int64
+)
+
+func digitsOrSpecialFilter(specials string) *CharFilter {
+	if specials == "" {
+		return digitsFilter
+	}
+	if f, ok := digitsOrSpecialFilters.Load(specials); ok {
+		return f.(*CharFilter)
+	}
+	f := NewCharFilter(AllowRange('0', '9'), AllowRunes(specials))
+	if atomic.AddInt64(&digitsOrSpecialFiltersLen, 1) > maxDigitsOrSpecialFilters {
+		atomic.AddInt64(&digitsOrSpecialFiltersLen, -1)
+		return f
+	}
+	cached, loaded := digitsOrSpecialFilters.LoadOrStore(specials, f)
+	if loaded {
+		atomic.AddInt64(&digitsOrSpecialFiltersLen, -1)
+	}
+	return cached.(*CharFilter)
+}
@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharFilter(t *testing.T) {
+	alphaNum := NewCharFilter(AllowRange('a', 'z'), AllowRange('A', 'Z'), AllowRange('0', '9'))
+	letters := NewCharFilter(AllowClass(unicode.L), AllowRunes(" "))
+	phone := NewCharFilter(AllowRange('0', '9'), AllowRunes("+"))
+
+	tests := []struct {
+		name   string
+		filter *CharFilter
+		input  string
+		want   string
+	}{
+		{"ascii ranges", alphaNum, "Hotel-123 São", "Hotel123So"},
+		{"unicode class", letters, "São Paulo, 2020!", "São Paulo "},
+		{"explicit runes", phone, "+55 (21) 9999-8888", "+552199998888"},
+		{"nothing removed", phone, "+5521", "+5521"},
+		{"invalid utf-8", phone, "12\xff34", "1234"},
+		{"empty", phone, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Filter(tt.input))
+			assert.Equal(t, tt.want, string(tt.filter.FilterBytes([]byte(tt.input))))
+			assert.Equal(t, tt.want, *tt.filter.FilterPtr(&tt.input))
+		})
+	}
+
+	assert.Nil(t, phone.FilterPtr(nil))
+	assert.True(t, letters.Allows('ç'))
+	assert.False(t, letters.Allows('1'))
+}
+
+func TestCharFilterConcurrentUse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := fmt.Sprintf("+55 (21) %d-%d", i, i)
+			specials := "+()"
+			if i%2 == 0 {
+				specials = "+"
+			}
+			result := *GetOnlyNumbersOrSpecial(&s, specials)
+			assert.True(t, strings.HasPrefix(result, "+55"))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDigitsOrSpecialFiltersBounded(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4*maxDigitsOrSpecialFilters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			special := string(rune(0x100 + i))
+			s := "1a" + special + "2"
+			assert.Equal(t, "1"+special+"2", *GetOnlyNumbersOrSpecial(&s, special))
+		}(i)
+	}
+	wg.Wait()
+
+	cached := 0
+	digitsOrSpecialFilters.Range(func(_, _ interface{}) bool {
+		cached++
+		return true
+	})
+	assert.True(t, cached <= maxDigitsOrSpecialFilters, "%d filters cached", cached)
+}
+
+// legacyGetOnlyNumbersOrSpecial is the regexp based implementation replaced
+// by CharFilter, kept to compare performance
+func legacyGetOnlyNumbersOrSpecial(s *string, sp string) *string {
+	if s == nil {
+		return s
+	}
+	specials := ""
+	if len(sp) > 0 {
+		for _, item := range strings.Split(sp, "") {
+			specials = specials + `\` + item
+		}
+	}
+	pattern := fmt.Sprintf(`[^%s0-9]`, specials)
+	r := regexp.MustCompile(pattern)
+	result := r.ReplaceAllString(*s, "")
+	return &result
+}
+
+var benchmarkPhone = "+55 (21) 98765-4321"
+
+func BenchmarkGetOnlyNumbers(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		GetOnlyNumbers(&benchmarkPhone)
+	}
+}
+
+func BenchmarkGetOnlyNumbersLegacy(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		legacyGetOnlyNumbersOrSpecial(&benchmarkPhone, "")
+	}
+}
+
+func BenchmarkGetOnlyNumbersOrSpecial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		GetOnlyNumbersOrSpecial(&benchmarkPhone, "+()")
+	}
+}
+
+func BenchmarkGetOnlyNumbersOrSpecialLegacy(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		legacyGetOnlyNumbersOrSpecial(&benchmarkPhone, "+()")
+	}
+}
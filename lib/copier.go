@@ -0,0 +1,311 @@
+package lib
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// CopyOptions configures CopyWithOptions
+type CopyOptions struct {
+	// Tag used to match fields by name. Fields are matched by tag name first
+	// and by Go name when no field has the same tag name
+	Tag string
+	// SkipZero leaves destination fields untouched when the source field is
+	// nil or zero, merging src into dst
+	SkipZero bool
+	// TimeLayout converts between time.Time and strings. When empty times are
+	// written as RFC 3339 and strings are parsed by ParseDateStringToTime
+	TimeLayout string
+}
+
+// Copy copies the fields of src into the struct pointed by dst, matching
+// them by json tag or Go name. See CopyWithOptions
+func Copy(dst, src interface{}) error {
+	return CopyWithOptions(dst, src, CopyOptions{Tag: "json"})
+}
+
+// CopyWithOptions copies the fields of src into the struct pointed by dst.
+// Values of different types are converted when possible: numbers and
+// booleans to and from strings through the lib parsers, times to and from
+// strings, numbers between each other and nested structs, pointers, slices
+// and maps element by element. Empty strings become the zero value of the
+// destination type. Unmatched fields are ignored. An error naming
+// the field path is returned when a value cannot be converted:
+//
+//	var dto BookingDTO
+//	err := CopyWithOptions(&dto, booking, CopyOptions{Tag: "json", TimeLayout: DatePatternYYYYMMDD})
+func CopyWithOptions(dst, src interface{}, opts CopyOptions) error {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() {
+		return errors.Errorf("Copy: expected non-nil pointer, got %T", dst)
+	}
+	c := copier{opts: opts}
+	if err := c.copy(dstValue.Elem(), reflect.ValueOf(src), ""); err != nil {
+		return errors.Wrap(err, "Copy")
+	}
+	return nil
+}
+
+type copier struct {
+	opts CopyOptions
+}
+
+// copyStep copies the field at src index into the field at dst index
+type copyStep struct {
+	src, dst []int
+	path     string
+}
+
+type copyPlanKey struct {
+	src, dst reflect.Type
+	tag      string
+}
+
+// copyPlans caches the steps used to copy between each pair of struct types
+var copyPlans sync.Map
+
+func copyPlan(src, dst reflect.Type, tag string) []copyStep {
+	key := copyPlanKey{src: src, dst: dst, tag: tag}
+	if plan, ok := copyPlans.Load(key); ok {
+		return plan.([]copyStep)
+	}
+
+	srcFields := StructFields(src, tag)
+	byTag := make(map[string]FieldInfo, len(srcFields))
+	byName := make(map[string]FieldInfo, len(srcFields))
+	for _, field := range srcFields {
+		byTag[field.TagName] = field
+		byName[field.Name] = field
+	}
+
+	var plan []copyStep
+	for _, field := range StructFields(dst, tag) {
+		srcField, ok := byTag[field.TagName]
+		if !ok {
+			srcField, ok = byName[field.Name]
+		}
+		if ok {
+			plan = append(plan, copyStep{src: srcField.Index, dst: field.Index, path: field.TagName})
+		}
+	}
+	copyPlans.Store(key, plan)
+	return plan
+}
+
+func (c copier) copy(dst, src reflect.Value, path string) error {
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		src = src.Elem()
+	}
+	if !src.IsValid() {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if !dst.IsNil() {
+			elem.Elem().Set(dst.Elem())
+		}
+		if err := c.copy(elem.Elem(), src, path); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	switch {
+	case dst.Type() == timeType || src.Type() == timeType:
+		return c.copyTime(dst, src, path)
+	case dst.Kind() == reflect.Struct && src.Kind() == reflect.Struct:
+		return c.copyStruct(dst, src, path)
+	case dst.Kind() == reflect.Slice && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := c.copy(slice.Index(i), src.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	case dst.Kind() == reflect.Array && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		for i := 0; i < dst.Len() && i < src.Len(); i++ {
+			if err := c.copy(dst.Index(i), src.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case dst.Kind() == reflect.Map && src.Kind() == reflect.Map:
+		return c.copyMap(dst, src, path)
+	}
+	return c.copyScalar(dst, src, path)
+}
+
+func (c copier) copyStruct(dst, src reflect.Value, path string) error {
+	for _, step := range copyPlan(src.Type(), dst.Type(), c.opts.Tag) {
+		srcField := src.FieldByIndex(step.src)
+		if c.opts.SkipZero && isZeroValue(srcField) {
+			continue
+		}
+		if err := c.copy(dst.FieldByIndex(step.dst), srcField, fieldPath(path, step.path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c copier) copyMap(dst, src reflect.Value, path string) error {
+	if src.IsNil() {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	m := reflect.MakeMapWithSize(dst.Type(), src.Len())
+	for _, key := range src.MapKeys() {
+		keyPath := fieldPath(path, cast.ToString(key.Interface()))
+		dstKey := reflect.New(dst.Type().Key()).Elem()
+		if err := c.copy(dstKey, key, keyPath); err != nil {
+			return err
+		}
+		dstElem := reflect.New(dst.Type().Elem()).Elem()
+		if err := c.copy(dstElem, src.MapIndex(key), keyPath); err != nil {
+			return err
+		}
+		m.SetMapIndex(dstKey, dstElem)
+	}
+	dst.Set(m)
+	return nil
+}
+
+func (c copier) copyTime(dst, src reflect.Value, path string) error {
+	switch {
+	case src.Type() == timeType && dst.Type() == timeType:
+		dst.Set(src)
+		return nil
+	case src.Type() == timeType && dst.Kind() == reflect.String:
+		t := src.Interface().(time.Time)
+		if c.opts.TimeLayout != "" {
+			dst.SetString(t.Format(c.opts.TimeLayout))
+		} else {
+			dst.SetString(t.Format(time.RFC3339))
+		}
+		return nil
+	case dst.Type() == timeType && src.Kind() == reflect.String:
+		if src.Len() == 0 {
+			dst.Set(reflect.Zero(timeType))
+			return nil
+		}
+		if c.opts.TimeLayout != "" {
+			t, err := time.Parse(c.opts.TimeLayout, src.String())
+			if err != nil {
+				return copyError(path, "must be a date in %s layout", c.opts.TimeLayout)
+			}
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if err := setFieldFromString(dst, src.String()); err != nil {
+			return copyError(path, "%s", err.Error())
+		}
+		return nil
+	}
+	return copyError(path, "cannot copy %s into %s", src.Type(), dst.Type())
+}
+
+func (c copier) copyScalar(dst, src reflect.Value, path string) error {
+	srcKind, dstKind := src.Kind(), dst.Kind()
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case dstKind == reflect.String && (srcKind == reflect.String || isNumericOrBoolKind(srcKind)):
+		dst.SetString(cast.ToString(src.Convert(basicType(srcKind)).Interface()))
+	case srcKind == reflect.String && src.Len() == 0:
+		dst.Set(reflect.Zero(dst.Type()))
+	case srcKind == reflect.String:
+		if err := setFieldFromString(dst, src.String()); err != nil {
+			return copyError(path, "%s", err.Error())
+		}
+	case isNumericKind(srcKind) && isNumericKind(dstKind):
+		if overflows(dst, src) {
+			return copyError(path, "%v does not fit in %s", src.Interface(), dst.Type())
+		}
+		dst.Set(src.Convert(dst.Type()))
+	case srcKind == reflect.Bool && dstKind == reflect.Bool:
+		dst.SetBool(src.Bool())
+	default:
+		return copyError(path, "cannot copy %s into %s", src.Type(), dst.Type())
+	}
+	return nil
+}
+
+// basicType returns the predeclared type of a kind, so named types can be
+// handed to cast
+func basicType(kind reflect.Kind) reflect.Type {
+	switch kind {
+	case reflect.Bool:
+		return reflect.TypeOf(false)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.TypeOf(int64(0))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.TypeOf(uint64(0))
+	case reflect.Float32, reflect.Float64:
+		return reflect.TypeOf(float64(0))
+	}
+	return reflect.TypeOf("")
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+// overflows reports whether the number held by src does not fit in dst
+func overflows(dst, src reflect.Value) bool {
+	srcKind, dstKind := src.Kind(), dst.Kind()
+	switch {
+	case isIntKind(srcKind) && isIntKind(dstKind):
+		return dst.OverflowInt(src.Int())
+	case isIntKind(srcKind) && isUintKind(dstKind):
+		return src.Int() < 0 || dst.OverflowUint(uint64(src.Int()))
+	case isUintKind(srcKind) && isIntKind(dstKind):
+		return src.Uint() > 1<<63-1 || dst.OverflowInt(int64(src.Uint()))
+	case isUintKind(srcKind) && isUintKind(dstKind):
+		return dst.OverflowUint(src.Uint())
+	case isFloatKind(srcKind) && isFloatKind(dstKind):
+		return dst.OverflowFloat(src.Float())
+	case isFloatKind(srcKind) && isIntKind(dstKind):
+		f := src.Float()
+		return f != float64(int64(f)) || dst.OverflowInt(int64(f))
+	case isFloatKind(srcKind) && isUintKind(dstKind):
+		f := src.Float()
+		return f < 0 || f != float64(uint64(f)) || dst.OverflowUint(uint64(f))
+	}
+	return false
+}
+
+func copyError(path, format string, args ...interface{}) error {
+	if path == "" {
+		return errors.Errorf(format, args...)
+	}
+	return errors.Errorf("%s: "+format, append([]interface{}{path}, args...)...)
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
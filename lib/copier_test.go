@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type copyRoom struct {
+	Number int    `json:"number"`
+	Type   string `json:"type"`
+}
+
+type copyBooking struct {
+	ID       int64             `json:"id"`
+	Hotel    string            `json:"hotel"`
+	Price    float64           `json:"price"`
+	Nights   string            `json:"nights"`
+	CheckIn  time.Time         `json:"check_in"`
+	Paid     bool              `json:"paid"`
+	Rooms    []copyRoom        `json:"rooms"`
+	Main     *copyRoom         `json:"main_room"`
+	Extras   map[string]int    `json:"extras"`
+	Notes    string            `json:"-"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type copyRoomDTO struct {
+	Number string `json:"number"`
+	Kind   string `json:"type"`
+}
+
+type copyBookingDTO struct {
+	ID       string             `json:"id"`
+	Hotel    *string            `json:"hotel"`
+	Price    string             `json:"price"`
+	Nights   int                `json:"nights"`
+	CheckIn  string             `json:"check_in"`
+	Paid     string             `json:"paid"`
+	Rooms    []copyRoomDTO      `json:"rooms"`
+	Main     copyRoomDTO        `json:"main_room"`
+	Extras   map[string]float64 `json:"extras"`
+	Notes    string
+	Metadata map[string]string `json:"metadata"`
+}
+
+func TestCopy(t *testing.T) {
+	checkIn := time.Date(2018, 10, 5, 14, 0, 0, 0, time.UTC)
+	booking := copyBooking{
+		ID:       42,
+		Hotel:    "Copacabana Palace",
+		Price:    1250.5,
+		Nights:   "3",
+		CheckIn:  checkIn,
+		Paid:     true,
+		Rooms:    []copyRoom{{Number: 101, Type: "suite"}, {Number: 102, Type: "standard"}},
+		Main:     &copyRoom{Number: 101, Type: "suite"},
+		Extras:   map[string]int{"breakfast": 2},
+		Notes:    "VIP",
+		Metadata: map[string]string{"channel": "app"},
+	}
+
+	var dto copyBookingDTO
+	assert.NoError(t, Copy(&dto, booking))
+
+	hotel := "Copacabana Palace"
+	assert.Equal(t, copyBookingDTO{
+		ID:       "42",
+		Hotel:    &hotel,
+		Price:    "1250.5",
+		Nights:   3,
+		CheckIn:  "2018-10-05T14:00:00Z",
+		Paid:     "true",
+		Rooms:    []copyRoomDTO{{Number: "101", Kind: "suite"}, {Number: "102", Kind: "standard"}},
+		Main:     copyRoomDTO{Number: "101", Kind: "suite"},
+		Extras:   map[string]float64{"breakfast": 2},
+		Metadata: map[string]string{"channel": "app"},
+	}, dto)
+
+	var back copyBooking
+	assert.NoError(t, Copy(&back, &dto))
+	back.Notes = "VIP"
+	assert.Equal(t, booking, back)
+}
+
+func TestCopyWithOptions(t *testing.T) {
+	t.Run("time layout", func(t *testing.T) {
+		src := struct{ CheckIn time.Time }{time.Date(2018, 10, 5, 0, 0, 0, 0, time.UTC)}
+		var dst struct{ CheckIn string }
+		assert.NoError(t, CopyWithOptions(&dst, src, CopyOptions{TimeLayout: DatePatternYYYYMMDD}))
+		assert.Equal(t, "2018-10-05", dst.CheckIn)
+
+		var parsed struct{ CheckIn time.Time }
+		assert.NoError(t, CopyWithOptions(&parsed, dst, CopyOptions{TimeLayout: DatePatternYYYYMMDD}))
+		assert.Equal(t, src.CheckIn, parsed.CheckIn)
+	})
+
+	t.Run("skip zero", func(t *testing.T) {
+		dst := copyBooking{ID: 1, Hotel: "Fasano", Main: &copyRoom{Number: 7, Type: "suite"}}
+		src := copyBooking{Hotel: "Fasano Rio", Main: &copyRoom{Number: 8}}
+		assert.NoError(t, CopyWithOptions(&dst, src, CopyOptions{Tag: "json", SkipZero: true}))
+		assert.Equal(t, int64(1), dst.ID)
+		assert.Equal(t, "Fasano Rio", dst.Hotel)
+		assert.Equal(t, &copyRoom{Number: 8, Type: "suite"}, dst.Main)
+	})
+
+	t.Run("without skip zero", func(t *testing.T) {
+		dst := copyBooking{ID: 1, Main: &copyRoom{Number: 7}}
+		assert.NoError(t, CopyWithOptions(&dst, copyBooking{}, CopyOptions{Tag: "json"}))
+		assert.Equal(t, copyBooking{}, dst)
+	})
+
+	t.Run("matches go name without tag", func(t *testing.T) {
+		src := struct {
+			Name string `json:"hotel_name"`
+		}{"Fasano"}
+		var dst struct{ Name string }
+		assert.NoError(t, CopyWithOptions(&dst, src, CopyOptions{Tag: "json"}))
+		assert.Equal(t, "Fasano", dst.Name)
+	})
+}
+
+func TestCopyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  interface{}
+		src  interface{}
+		want string
+	}{
+		{"not a pointer", copyBookingDTO{}, copyBooking{}, "Copy: expected non-nil pointer, got lib.copyBookingDTO"},
+		{"invalid number", &copyBooking{}, copyBookingDTO{Nights: 1, ID: "abc"}, "Copy: id: must be an integer"},
+		{"invalid date", &copyBooking{}, copyBookingDTO{CheckIn: "tomorrow"}, "Copy: check_in: must be a date"},
+		{"nested", &copyBooking{}, copyBookingDTO{Rooms: []copyRoomDTO{{Number: "1"}, {Number: "x"}}}, "Copy: rooms[1].number: must be an integer"},
+		{"overflow", &struct{ N int8 }{}, struct{ N int }{300}, "Copy: N: 300 does not fit in int8"},
+		{"fraction", &struct{ N int }{}, struct{ N float64 }{1.5}, "Copy: N: 1.5 does not fit in int"},
+		{"incompatible", &struct{ N int }{}, struct{ N []int }{[]int{1}}, "Copy: N: cannot copy []int into int"},
+		{"percent in map key", &map[string]int{}, map[string]string{"100%d": "x"}, "Copy: 100%d: must be an integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Copy(tt.dst, tt.src)
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.want, err.Error())
+			}
+		})
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	booking := copyBooking{
+		ID:    42,
+		Hotel: "Copacabana Palace",
+		Rooms: []copyRoom{{Number: 101, Type: "suite"}},
+	}
+	for i := 0; i < b.N; i++ {
+		var dto copyBookingDTO
+		if err := Copy(&dto, booking); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChangeOp is the kind of a Change, named after the JSON Patch operations
+type ChangeOp string
+
+const (
+	// ChangeAdd is a value present only in the new value
+	ChangeAdd ChangeOp = "add"
+	// ChangeRemove is a value present only in the old value
+	ChangeRemove ChangeOp = "remove"
+	// ChangeReplace is a value present in both with different contents
+	ChangeReplace ChangeOp = "replace"
+)
+
+// Change is a difference found by Diff
+type Change struct {
+	Op ChangeOp
+	// Path from the root to the changed value, made of tag names, slice
+	// indexes and map keys. Slice elements added at the end use "-"
+	Path []string
+	Old  interface{}
+	New  interface{}
+}
+
+// Pointer returns the path as a JSON Pointer (RFC 6901), e.g. /rooms/0/type
+func (c Change) Pointer() string {
+	var b strings.Builder
+	for _, segment := range c.Path {
+		b.WriteByte('/')
+		b.WriteString(jsonPointerEscaper.Replace(segment))
+	}
+	return b.String()
+}
+
+func (c Change) String() string {
+	path := strings.Join(c.Path, ".")
+	switch c.Op {
+	case ChangeAdd:
+		return fmt.Sprintf("add %s: %v", path, c.New)
+	case ChangeRemove:
+		return fmt.Sprintf("remove %s: %v", path, c.Old)
+	}
+	return fmt.Sprintf("replace %s: %v -> %v", path, c.Old, c.New)
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// DiffOptions configures DiffWithOptions
+type DiffOptions struct {
+	// Tag naming the path segments of struct fields, json when empty.
+	// Fields tagged "-" are not compared
+	Tag string
+	// TimePrecision truncates times before comparing them. time.Second
+	// ignores the same nanoseconds dropped by RemoveNanoseconds. Zero compares
+	// the exact instant, ignoring the location
+	TimePrecision time.Duration
+	// SliceKeys matches the elements of slices of the given struct types by a
+	// key field, given by tag or Go name, instead of by index. Matched
+	// elements are compared wherever they are, so reordering is not a change
+	SliceKeys map[reflect.Type]string
+}
+
+// Diff compares two values of the same type with the default options. See
+// DiffWithOptions
+func Diff(oldValue, newValue interface{}) ([]Change, error) {
+	return DiffWithOptions(oldValue, newValue, DiffOptions{})
+}
+
+// DiffWithOptions walks two values of the same type and returns the changes
+// needed to turn oldValue into newValue, in an order that can be applied
+// as a JSON Patch:
+//
+//	changes, err := Diff(before, after)
+//	for _, change := range changes {
+//		log.Printf("booking %d: %s", after.ID, change)
+//	}
+//
+// Structs are compared field by field, maps key by key and slices index by
+// index or by the key field set in SliceKeys
+func DiffWithOptions(oldValue, newValue interface{}, opts DiffOptions) ([]Change, error) {
+	if opts.Tag == "" {
+		opts.Tag = "json"
+	}
+	oldV, newV := reflect.ValueOf(oldValue), reflect.ValueOf(newValue)
+	if oldV.IsValid() && newV.IsValid() && IndirectType(oldV.Type()) != IndirectType(newV.Type()) {
+		return nil, errors.Errorf("Diff: different types %T and %T", oldValue, newValue)
+	}
+	d := differ{opts: opts}
+	d.diff(oldV, newV, nil)
+	return d.changes, nil
+}
+
+type differ struct {
+	opts    DiffOptions
+	changes []Change
+}
+
+func (d *differ) add(op ChangeOp, path []string, oldValue, newValue reflect.Value) {
+	d.changes = append(d.changes, Change{
+		Op:   op,
+		Path: append([]string{}, path...),
+		Old:  valueInterface(oldValue),
+		New:  valueInterface(newValue),
+	})
+}
+
+func (d *differ) diff(oldValue, newValue reflect.Value, path []string) {
+	oldValue, newValue = IndirectValue(oldValue), IndirectValue(newValue)
+	switch {
+	case !oldValue.IsValid() && !newValue.IsValid():
+		return
+	case !oldValue.IsValid() || !newValue.IsValid() || oldValue.Type() != newValue.Type():
+		d.add(ChangeReplace, path, oldValue, newValue)
+		return
+	}
+
+	switch oldValue.Kind() {
+	case reflect.Struct:
+		if oldValue.Type() == timeType {
+			if !d.equalTimes(oldValue.Interface().(time.Time), newValue.Interface().(time.Time)) {
+				d.add(ChangeReplace, path, oldValue, newValue)
+			}
+			return
+		}
+		fields := StructFields(oldValue.Type(), d.opts.Tag)
+		if len(fields) == 0 {
+			d.diffLeaf(oldValue, newValue, path)
+			return
+		}
+		for _, field := range fields {
+			d.diff(oldValue.FieldByIndex(field.Index), newValue.FieldByIndex(field.Index), append(path, field.TagName))
+		}
+	case reflect.Map:
+		d.diffMap(oldValue, newValue, path)
+	case reflect.Slice, reflect.Array:
+		if oldValue.Kind() == reflect.Slice && oldValue.IsNil() != newValue.IsNil() {
+			d.add(ChangeReplace, path, oldValue, newValue)
+			return
+		}
+		if key, ok := d.opts.SliceKeys[IndirectType(oldValue.Type().Elem())]; ok {
+			d.diffKeyedSlice(oldValue, newValue, path, key)
+			return
+		}
+		d.diffSlice(oldValue, newValue, path)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return
+	default:
+		d.diffLeaf(oldValue, newValue, path)
+	}
+}
+
+func (d *differ) diffLeaf(oldValue, newValue reflect.Value, path []string) {
+	if !reflect.DeepEqual(valueInterface(oldValue), valueInterface(newValue)) {
+		d.add(ChangeReplace, path, oldValue, newValue)
+	}
+}
+
+func (d *differ) diffMap(oldValue, newValue reflect.Value, path []string) {
+	if oldValue.IsNil() != newValue.IsNil() {
+		d.add(ChangeReplace, path, oldValue, newValue)
+		return
+	}
+	for _, key := range sortedMapKeys(oldValue) {
+		keyPath := append(path, fmt.Sprint(key.Interface()))
+		if newElem := newValue.MapIndex(key); newElem.IsValid() {
+			d.diff(oldValue.MapIndex(key), newElem, keyPath)
+		} else {
+			d.add(ChangeRemove, keyPath, oldValue.MapIndex(key), reflect.Value{})
+		}
+	}
+	for _, key := range sortedMapKeys(newValue) {
+		if !oldValue.MapIndex(key).IsValid() {
+			d.add(ChangeAdd, append(path, fmt.Sprint(key.Interface())), reflect.Value{}, newValue.MapIndex(key))
+		}
+	}
+}
+
+// diffSlice compares elements by index. Extra old elements are removed from
+// the last one so the indexes stay valid when applied in order
+func (d *differ) diffSlice(oldValue, newValue reflect.Value, path []string) {
+	common := oldValue.Len()
+	if newValue.Len() < common {
+		common = newValue.Len()
+	}
+	for i := 0; i < common; i++ {
+		d.diff(oldValue.Index(i), newValue.Index(i), append(path, strconv.Itoa(i)))
+	}
+	for i := oldValue.Len() - 1; i >= common; i-- {
+		d.add(ChangeRemove, append(path, strconv.Itoa(i)), oldValue.Index(i), reflect.Value{})
+	}
+	for i := common; i < newValue.Len(); i++ {
+		d.add(ChangeAdd, append(path, strconv.Itoa(i)), reflect.Value{}, newValue.Index(i))
+	}
+}
+
+// diffKeyedSlice matches elements by key field. Matched elements are
+// compared at their old index, unmatched old elements are removed from the
+// last one and unmatched new elements are appended. Elements are compared by
+// index when a key cannot index a map, e.g. an interface holding a slice
+func (d *differ) diffKeyedSlice(oldValue, newValue reflect.Value, path []string, key string) {
+	oldKeys, oldHashable := d.sliceKeys(oldValue, key)
+	newKeys, newHashable := d.sliceKeys(newValue, key)
+	if !oldHashable || !newHashable {
+		d.diffSlice(oldValue, newValue, path)
+		return
+	}
+
+	newIndexes := make(map[interface{}]int, newValue.Len())
+	for j, k := range newKeys {
+		if k != noSliceKey {
+			newIndexes[k] = j
+		}
+	}
+
+	matched := make(map[int]bool, newValue.Len())
+	var removed []int
+	for i, k := range oldKeys {
+		j, found := newIndexes[k]
+		if k == noSliceKey || !found || matched[j] {
+			removed = append(removed, i)
+			continue
+		}
+		matched[j] = true
+		d.diff(oldValue.Index(i), newValue.Index(j), append(path, strconv.Itoa(i)))
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		d.add(ChangeRemove, append(path, strconv.Itoa(removed[i])), oldValue.Index(removed[i]), reflect.Value{})
+	}
+	for j := 0; j < newValue.Len(); j++ {
+		if !matched[j] {
+			d.add(ChangeAdd, append(path, "-"), reflect.Value{}, newValue.Index(j))
+		}
+	}
+}
+
+// noSliceKey is the key of the elements without key field
+var noSliceKey interface{} = struct{ noSliceKey bool }{}
+
+// sliceKeys returns the key of each element of slice, noSliceKey for the ones
+// without key field. It returns false when a key cannot index a map
+func (d *differ) sliceKeys(slice reflect.Value, key string) ([]interface{}, bool) {
+	keys := make([]interface{}, slice.Len())
+	for i := range keys {
+		k, ok := d.sliceKey(slice.Index(i), key)
+		if !ok {
+			keys[i] = noSliceKey
+			continue
+		}
+		if !isHashable(k) {
+			return nil, false
+		}
+		keys[i] = k
+	}
+	return keys, true
+}
+
+func (d *differ) sliceKey(elem reflect.Value, key string) (interface{}, bool) {
+	elem = IndirectValue(elem)
+	if !elem.IsValid() || elem.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, field := range StructFields(elem.Type(), d.opts.Tag) {
+		if field.TagName == key || field.Name == key {
+			value := elem.FieldByIndex(field.Index)
+			if !value.Type().Comparable() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// isHashable reports whether k can index a map, which depends on the dynamic
+// types held by its interfaces
+func isHashable(k interface{}) (hashable bool) {
+	defer func() {
+		if recover() != nil {
+			hashable = false
+		}
+	}()
+	_ = map[interface{}]bool{k: true}
+	return true
+}
+
+func (d *differ) equalTimes(a, b time.Time) bool {
+	if d.opts.TimePrecision > 0 {
+		a, b = a.Truncate(d.opts.TimePrecision), b.Truncate(d.opts.TimePrecision)
+	}
+	return a.Equal(b)
+}
+
+func valueInterface(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+// PatchOperation is a JSON Patch (RFC 6902) operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON omits the value of remove operations only, since add, replace
+// and test require it even when null
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == string(ChangeRemove) {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	type operation PatchOperation
+	return json.Marshal(operation(o))
+}
+
+// JSONPatch renders changes as JSON Patch operations, ready to be encoded
+// with json.Marshal
+func JSONPatch(changes []Change) []PatchOperation {
+	patch := make([]PatchOperation, len(changes))
+	for i, change := range changes {
+		patch[i] = PatchOperation{Op: string(change.Op), Path: change.Pointer(), Value: change.New}
+	}
+	return patch
+}
@@ -0,0 +1,201 @@
+package lib
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffGuest struct {
+	Document string `json:"document"`
+	Name     string `json:"name"`
+	Age      int    `json:"age"`
+}
+
+type diffReservation struct {
+	ID       int64             `json:"id"`
+	Hotel    string            `json:"hotel"`
+	CheckIn  time.Time         `json:"check_in"`
+	Guests   []diffGuest       `json:"guests"`
+	Tags     []string          `json:"tags"`
+	Extras   map[string]int    `json:"extras"`
+	Contact  *diffGuest        `json:"contact"`
+	Internal string            `json:"-"`
+	Notes    map[string]string `json:"notes,omitempty"`
+}
+
+func newDiffReservation() diffReservation {
+	return diffReservation{
+		ID:      42,
+		Hotel:   "Copacabana Palace",
+		CheckIn: time.Date(2018, 10, 5, 14, 0, 0, 0, time.UTC),
+		Guests: []diffGuest{
+			{Document: "111", Name: "Ana", Age: 30},
+			{Document: "222", Name: "Bruno", Age: 8},
+		},
+		Tags:    []string{"vip", "late-checkout"},
+		Extras:  map[string]int{"breakfast": 2, "parking": 1},
+		Contact: &diffGuest{Name: "Ana"},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := newDiffReservation()
+	after := newDiffReservation()
+	after.Hotel = "Fasano"
+	after.Guests[1].Age = 9
+	after.Tags = []string{"vip"}
+	after.Extras = map[string]int{"breakfast": 3, "spa": 1}
+	after.Contact = nil
+	after.Internal = "ignored"
+
+	changes, err := Diff(before, &after)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Op: ChangeReplace, Path: []string{"hotel"}, Old: "Copacabana Palace", New: "Fasano"},
+		{Op: ChangeReplace, Path: []string{"guests", "1", "age"}, Old: 8, New: 9},
+		{Op: ChangeRemove, Path: []string{"tags", "1"}, Old: "late-checkout"},
+		{Op: ChangeReplace, Path: []string{"extras", "breakfast"}, Old: 2, New: 3},
+		{Op: ChangeRemove, Path: []string{"extras", "parking"}, Old: 1},
+		{Op: ChangeAdd, Path: []string{"extras", "spa"}, New: 1},
+		{Op: ChangeReplace, Path: []string{"contact"}, Old: diffGuest{Name: "Ana"}},
+	}, changes)
+
+	changes, err = Diff(before, newDiffReservation())
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = Diff(before, 10)
+	assert.Error(t, err)
+}
+
+func TestDiffSlices(t *testing.T) {
+	before := newDiffReservation()
+	after := newDiffReservation()
+	after.Guests = []diffGuest{
+		{Document: "222", Name: "Bruno", Age: 9},
+		{Document: "333", Name: "Carla", Age: 40},
+	}
+
+	t.Run("by index", func(t *testing.T) {
+		changes, err := Diff(before, after)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"replace guests.0.document: 111 -> 222",
+			"replace guests.0.name: Ana -> Bruno",
+			"replace guests.0.age: 30 -> 9",
+			"replace guests.1.document: 222 -> 333",
+			"replace guests.1.name: Bruno -> Carla",
+			"replace guests.1.age: 8 -> 40",
+		}, changeStrings(changes))
+	})
+
+	t.Run("by key", func(t *testing.T) {
+		opts := DiffOptions{SliceKeys: map[reflect.Type]string{reflect.TypeOf(diffGuest{}): "document"}}
+		changes, err := DiffWithOptions(before, after, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"replace guests.1.age: 8 -> 9",
+			"remove guests.0: {111 Ana 30}",
+			"add guests.-: {333 Carla 40}",
+		}, changeStrings(changes))
+	})
+
+	t.Run("unhashable keys are compared by index", func(t *testing.T) {
+		type item struct {
+			K interface{}
+			N int
+		}
+		opts := DiffOptions{SliceKeys: map[reflect.Type]string{reflect.TypeOf(item{}): "K"}}
+		var changes []Change
+		var err error
+		assert.NotPanics(t, func() {
+			changes, err = DiffWithOptions(
+				[]item{{K: []int{1}, N: 1}, {K: "b", N: 2}},
+				[]item{{K: []int{1}, N: 3}, {K: "b", N: 2}},
+				opts)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"replace 0.N: 1 -> 3"}, changeStrings(changes))
+	})
+
+	t.Run("added at the end", func(t *testing.T) {
+		grown := newDiffReservation()
+		grown.Tags = append(grown.Tags, "pet")
+		changes, err := Diff(before, grown)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"add tags.2: pet"}, changeStrings(changes))
+	})
+}
+
+func TestDiffTimePrecision(t *testing.T) {
+	before := newDiffReservation()
+	after := newDiffReservation()
+	after.CheckIn = before.CheckIn.Add(300 * time.Millisecond).In(time.FixedZone("BRT", -3*60*60))
+
+	changes, err := Diff(before, after)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	changes, err = DiffWithOptions(before, after, DiffOptions{TimePrecision: time.Second})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	withoutNanoseconds, err := RemoveNanoseconds(after.CheckIn)
+	assert.NoError(t, err)
+	assert.True(t, withoutNanoseconds.Equal(before.CheckIn))
+}
+
+func TestJSONPatch(t *testing.T) {
+	before := newDiffReservation()
+	after := newDiffReservation()
+	after.Guests[0].Age = 0
+	after.Extras = map[string]int{"breakfast": 2, "parking": 1, "a/b~c": 1}
+	after.Tags = nil
+
+	changes, err := Diff(before, after)
+	assert.NoError(t, err)
+
+	patch, err := json.Marshal(JSONPatch(changes))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "replace", "path": "/guests/0/age", "value": 0},
+		{"op": "replace", "path": "/tags", "value": null},
+		{"op": "add", "path": "/extras/a~1b~0c", "value": 1}
+	]`, string(patch))
+}
+
+func TestJSONPatchNilValues(t *testing.T) {
+	before := newDiffReservation()
+	after := newDiffReservation()
+	after.Contact = nil
+	after.Extras = nil
+	after.Guests = after.Guests[:1]
+
+	changes, err := Diff(before, after)
+	assert.NoError(t, err)
+	body, err := json.Marshal(JSONPatch(changes))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "remove", "path": "/guests/1"},
+		{"op": "replace", "path": "/extras", "value": null},
+		{"op": "replace", "path": "/contact", "value": null}
+	]`, string(body))
+
+	var patch []PatchOperation
+	assert.NoError(t, json.Unmarshal(body, &patch))
+	roundTrip, err := json.Marshal(patch)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(body), string(roundTrip))
+}
+
+func changeStrings(changes []Change) []string {
+	result := make([]string, len(changes))
+	for i, change := range changes {
+		result[i] = change.String()
+	}
+	return result
+}
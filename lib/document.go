@@ -0,0 +1,190 @@
+package lib
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidCPF is returned when a CPF fails the check digit verification
+	ErrInvalidCPF = errors.New("invalid CPF")
+	// ErrInvalidCNPJ is returned when a CNPJ fails the check digit verification
+	ErrInvalidCNPJ = errors.New("invalid CNPJ")
+	// ErrInvalidCEP is returned when a CEP does not have eight digits
+	ErrInvalidCEP = errors.New("invalid CEP")
+	// ErrInvalidPhone is returned when a phone number cannot be normalized
+	ErrInvalidPhone = errors.New("invalid phone number")
+)
+
+const brazilCountryCode = "55"
+
+var cnpjMaskReplacer = strings.NewReplacer(".", "", "/", "", "-", "", " ", "")
+
+// IsValidCPF reports whether the CPF, masked or not, has valid check digits.
+// Characters other than digits, dots, dashes and spaces are rejected
+func IsValidCPF(cpf string) bool {
+	if *GetOnlyNumbersOrSpecial(&cpf, ".- ") != cpf {
+		return false
+	}
+	digits := *GetOnlyNumbers(&cpf)
+	if len(digits) != 11 || strings.Count(digits, digits[:1]) == 11 {
+		return false
+	}
+	return mod11CheckDigit(digits[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[9] &&
+		mod11CheckDigit(digits[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[10]
+}
+
+// FormatCPF returns the CPF in the 000.000.000-00 mask
+func FormatCPF(cpf string) (string, error) {
+	if !IsValidCPF(cpf) {
+		return "", ErrInvalidCPF
+	}
+	d := *GetOnlyNumbers(&cpf)
+	return d[:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:], nil
+}
+
+// NormalizeCNPJ removes the mask of a CNPJ and upper cases its letters
+func NormalizeCNPJ(cnpj string) string {
+	return strings.ToUpper(cnpjMaskReplacer.Replace(strings.TrimSpace(cnpj)))
+}
+
+// IsValidCNPJ reports whether the CNPJ, masked or not, has valid check
+// digits. Both the numeric and the alphanumeric formats are accepted: the
+// first twelve characters may be digits or upper case letters, the last two
+// are always numeric check digits
+func IsValidCNPJ(cnpj string) bool {
+	c := NormalizeCNPJ(cnpj)
+	if len(c) != 14 || strings.Count(c, c[:1]) == 14 {
+		return false
+	}
+	for i := 0; i < 12; i++ {
+		if !isDigit(c[i]) && (c[i] < 'A' || c[i] > 'Z') {
+			return false
+		}
+	}
+	if !isDigit(c[12]) || !isDigit(c[13]) {
+		return false
+	}
+	return mod11CheckDigit(c[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == c[12] &&
+		mod11CheckDigit(c[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == c[13]
+}
+
+// FormatCNPJ returns the CNPJ in the 00.000.000/0000-00 mask
+func FormatCNPJ(cnpj string) (string, error) {
+	if !IsValidCNPJ(cnpj) {
+		return "", ErrInvalidCNPJ
+	}
+	c := NormalizeCNPJ(cnpj)
+	return c[:2] + "." + c[2:5] + "." + c[5:8] + "/" + c[8:12] + "-" + c[12:], nil
+}
+
+// IsValidCEP reports whether the CEP, masked or not, has eight digits
+func IsValidCEP(cep string) bool {
+	cep = strings.TrimSpace(cep)
+	if *GetOnlyNumbersOrSpecial(&cep, "-.") != cep {
+		return false
+	}
+	digits := *GetOnlyNumbers(&cep)
+	return len(digits) == 8 && digits != "00000000"
+}
+
+// FormatCEP returns the CEP in the 00000-000 mask
+func FormatCEP(cep string) (string, error) {
+	if !IsValidCEP(cep) {
+		return "", ErrInvalidCEP
+	}
+	d := *GetOnlyNumbers(&cep)
+	return d[:5] + "-" + d[5:], nil
+}
+
+// NormalizePhone converts a phone number to the E.164 format, e.g.
+// "(21) 99999-8888" to "+5521999998888". Numbers without country code are
+// handled as Brazilian ones, with or without the leading trunk zero
+func NormalizePhone(phone string) (string, error) {
+	digits := *GetOnlyNumbersOrSpecial(&phone, "+")
+	international := strings.HasPrefix(digits, "+")
+	digits = *GetOnlyNumbers(&digits)
+
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	if international && !strings.HasPrefix(digits, brazilCountryCode) {
+		if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+			return "", ErrInvalidPhone
+		}
+		return "+" + digits, nil
+	}
+
+	national := digits
+	if international {
+		national = digits[len(brazilCountryCode):]
+	} else {
+		national = strings.TrimLeft(national, "0")
+		if len(national) > 11 && strings.HasPrefix(national, brazilCountryCode) {
+			national = national[len(brazilCountryCode):]
+		}
+	}
+
+	if !isValidBrazilianPhone(national) {
+		return "", ErrInvalidPhone
+	}
+	return "+" + brazilCountryCode + national, nil
+}
+
+// IsValidPhone reports whether NormalizePhone accepts the phone number
+func IsValidPhone(phone string) bool {
+	_, err := NormalizePhone(phone)
+	return err == nil
+}
+
+// FormatPhone returns a Brazilian phone number in the (00) 00000-0000 mask.
+// Numbers from other countries are returned in the E.164 format
+func FormatPhone(phone string) (string, error) {
+	e164, err := NormalizePhone(phone)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(e164, "+"+brazilCountryCode) {
+		return e164, nil
+	}
+	n := e164[len(brazilCountryCode)+1:]
+	split := len(n) - 4
+	return "(" + n[:2] + ") " + n[2:split] + "-" + n[split:], nil
+}
+
+// isValidBrazilianPhone checks a national number: a two digit area code
+// followed by an eight digit landline or a nine digit mobile starting with 9
+func isValidBrazilianPhone(n string) bool {
+	if len(n) != 10 && len(n) != 11 {
+		return false
+	}
+	if n[0] == '0' || n[1] == '0' {
+		return false
+	}
+	if len(n) == 11 {
+		return n[2] == '9'
+	}
+	return n[2] >= '2' && n[2] <= '5'
+}
+
+// mod11CheckDigit computes a modulo 11 check digit. Characters are valued by
+// their ASCII code minus 48, so digits keep their value and letters are
+// supported as required by the alphanumeric CNPJ
+func mod11CheckDigit(s string, weights []int) byte {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(s[i]-'0') * weight
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,140 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidCPF(t *testing.T) {
+	tests := []struct {
+		cpf  string
+		want bool
+	}{
+		{"529.982.247-25", true},
+		{"52998224725", true},
+		{" 529 982 247 25 ", true},
+		{"529.982.247-26", false},
+		{"000.000.000-00", false},
+		{"111.111.111-11", false},
+		{"123", false},
+		{"", false},
+		{"529a982b247c25", false},
+		{"529.982.247/25", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cpf, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsValidCPF(tt.cpf))
+		})
+	}
+}
+
+func TestFormatCPF(t *testing.T) {
+	actual, err := FormatCPF("52998224725")
+	assert.Nil(t, err)
+	assert.Equal(t, "529.982.247-25", actual)
+
+	_, err = FormatCPF("52998224726")
+	assert.Equal(t, ErrInvalidCPF, err)
+}
+
+func TestIsValidCNPJ(t *testing.T) {
+	tests := []struct {
+		cnpj string
+		want bool
+	}{
+		{"11.222.333/0001-81", true},
+		{"11222333000181", true},
+		{"12.ABC.345/01DE-35", true},
+		{"12abc34501de35", true},
+		{"12.ABC.345/01DE-36", false},
+		{"12.ABC.345/01DE-3X", false},
+		{"11.222.333/0001-80", false},
+		{"00.000.000/0000-00", false},
+		{"11.222.333/0001", false},
+		{"11.222.333/0001-8Ç", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cnpj, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsValidCNPJ(tt.cnpj))
+		})
+	}
+}
+
+func TestFormatCNPJ(t *testing.T) {
+	actual, err := FormatCNPJ("11222333000181")
+	assert.Nil(t, err)
+	assert.Equal(t, "11.222.333/0001-81", actual)
+
+	actual, err = FormatCNPJ("12abc34501de35")
+	assert.Nil(t, err)
+	assert.Equal(t, "12.ABC.345/01DE-35", actual)
+
+	_, err = FormatCNPJ("11222333000180")
+	assert.Equal(t, ErrInvalidCNPJ, err)
+}
+
+func TestCEP(t *testing.T) {
+	assert.True(t, IsValidCEP("22041-001"))
+	assert.True(t, IsValidCEP("22041001"))
+	assert.True(t, IsValidCEP("22.041-001"))
+	assert.False(t, IsValidCEP("2204-1001x"))
+	assert.False(t, IsValidCEP("2204100"))
+	assert.False(t, IsValidCEP("00000-000"))
+
+	actual, err := FormatCEP("22041001")
+	assert.Nil(t, err)
+	assert.Equal(t, "22041-001", actual)
+
+	_, err = FormatCEP("123")
+	assert.Equal(t, ErrInvalidCEP, err)
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone   string
+		want    string
+		wantErr bool
+	}{
+		{phone: "(21) 99999-8888", want: "+5521999998888"},
+		{phone: "21 3333-4444", want: "+552133334444"},
+		{phone: "021 99999-8888", want: "+5521999998888"},
+		{phone: "+55 (21) 99999-8888", want: "+5521999998888"},
+		{phone: "0055 21 99999 8888", want: "+5521999998888"},
+		{phone: "5521999998888", want: "+5521999998888"},
+		{phone: "+1 (415) 555-2671", want: "+14155552671"},
+		{phone: "(21) 89999-8888", wantErr: true},
+		{phone: "(21) 9999-888", wantErr: true},
+		{phone: "(01) 3333-4444", wantErr: true},
+		{phone: "+1 234", wantErr: true},
+		{phone: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			got, err := NormalizePhone(tt.phone)
+			if tt.wantErr {
+				assert.Equal(t, ErrInvalidPhone, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatPhone(t *testing.T) {
+	actual, err := FormatPhone("+5521999998888")
+	assert.Nil(t, err)
+	assert.Equal(t, "(21) 99999-8888", actual)
+
+	actual, err = FormatPhone("2133334444")
+	assert.Nil(t, err)
+	assert.Equal(t, "(21) 3333-4444", actual)
+
+	actual, err = FormatPhone("+1 415 555 2671")
+	assert.Nil(t, err)
+	assert.Equal(t, "+14155552671", actual)
+
+	_, err = FormatPhone("abc")
+	assert.Equal(t, ErrInvalidPhone, err)
+}
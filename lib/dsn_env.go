@@ -0,0 +1,236 @@
+package lib
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDSNParams are the params added by DSNBuilder when absent, by scheme
+var DefaultDSNParams = map[string]url.Values{
+	DSNSchemeMySQL: {
+		"charset":      {"utf8mb4"},
+		"parseTime":    {"true"},
+		"timeout":      {"5s"},
+		"readTimeout":  {"30s"},
+		"writeTimeout": {"30s"},
+	},
+	DSNSchemePostgres: {
+		"connect_timeout": {"5"},
+	},
+	DSNSchemeRedis: {
+		"dial_timeout":  {"5s"},
+		"read_timeout":  {"30s"},
+		"write_timeout": {"30s"},
+	},
+	DSNSchemeMongo: {
+		"connectTimeoutMS": {"5000"},
+	},
+	DSNSchemeAMQP: {
+		"connection_timeout": {"5000"},
+	},
+}
+
+// defaultDSNPorts are the ports added to hosts without one, by scheme.
+// mongodb+srv has none since its hosts are resolved by SRV records
+var defaultDSNPorts = map[string]string{
+	DSNSchemeMySQL:    "3306",
+	DSNSchemePostgres: "5432",
+	"postgresql":      "5432",
+	DSNSchemeRedis:    "6379",
+	"rediss":          "6379",
+	DSNSchemeMongo:    "27017",
+	DSNSchemeAMQP:     "5672",
+	"amqps":           "5671",
+}
+
+// DSNBuilder assembles a DSN from prefixed environment variables. With the
+// DB prefix it reads:
+//
+//	DB_DSN       a complete DSN, completed by the other variables
+//	DB_DRIVER    mysql, postgres, redis, mongodb or amqp, Scheme when unset
+//	DB_HOST      one or more comma separated hosts, with optional ports
+//	DB_PORT      port of the hosts without one, the driver default when unset
+//	DB_NET       MySQL network, tcp when unset
+//	DB_USER
+//	DB_PASSWORD
+//	DB_NAME      database name
+//	DB_PARAMS    extra params as a query string, e.g. tls=true&loc=UTC
+//
+// Each variable can instead be read from the file named by the variable
+// with the _FILE suffix, e.g. DB_PASSWORD_FILE=/run/secrets/db_password
+type DSNBuilder struct {
+	// Prefix of the variables, without the trailing underscore
+	Prefix string
+	// Scheme used when DB_DRIVER is not set, mysql when empty
+	Scheme string
+	// Defaults are the params added when absent, DefaultDSNParams of the
+	// scheme when nil
+	Defaults url.Values
+	// LookupEnv reads the variables, os.LookupEnv when nil
+	LookupEnv func(key string) (string, bool)
+	// ReadFile reads _FILE secrets, ioutil.ReadFile when nil
+	ReadFile func(filename string) ([]byte, error)
+}
+
+// NewDSNBuilder creates a DSNBuilder reading variables with the given prefix
+// from the process environment
+func NewDSNBuilder(prefix string) *DSNBuilder {
+	return &DSNBuilder{Prefix: prefix}
+}
+
+// WithEnv makes the builder read variables from env instead of the process
+// environment, mostly for tests
+func (b *DSNBuilder) WithEnv(env map[string]string) *DSNBuilder {
+	b.LookupEnv = func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	return b
+}
+
+// Build assembles the DSN. It fails when a variable and its _FILE variant
+// are both set, when a secret file cannot be read and when the host or
+// database name are missing for drivers requiring them
+func (b *DSNBuilder) Build() (*DSN, error) {
+	v, err := b.variables("DSN", "DRIVER", "HOST", "PORT", "NET", "USER", "PASSWORD", "NAME", "PARAMS")
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := &DSN{Params: url.Values{}}
+	if v["DSN"] != "" {
+		if dsn, err = ParseDSN(v["DSN"]); err != nil {
+			return nil, errors.Wrap(err, b.name("DSN"))
+		}
+	}
+	if dsn.Scheme == "" {
+		dsn.Scheme = firstNonEmpty(v["DRIVER"], b.Scheme, DSNSchemeMySQL)
+		if dsn.Scheme == DSNSchemeMySQL {
+			dsn.Style = DSNStyleMySQL
+		}
+	}
+	if dsn.Style == DSNStyleMySQL && dsn.Net == "" {
+		dsn.Net = firstNonEmpty(v["NET"], "tcp")
+	}
+
+	if v["HOST"] != "" {
+		dsn.Hosts = strings.Split(v["HOST"], ",")
+	}
+	port := firstNonEmpty(v["PORT"], defaultDSNPorts[dsn.Scheme])
+	for i, host := range dsn.Hosts {
+		if dsn.Net != "unix" && port != "" && !hasPort(host) {
+			dsn.Hosts[i] = host + ":" + port
+		}
+	}
+	if v["USER"] != "" {
+		dsn.User = v["USER"]
+	}
+	if v["PASSWORD"] != "" {
+		dsn.Password = v["PASSWORD"]
+	}
+	if v["NAME"] != "" {
+		dsn.Database = v["NAME"]
+	}
+
+	if v["PARAMS"] != "" {
+		params, err := url.ParseQuery(v["PARAMS"])
+		if err != nil {
+			return nil, errors.Wrap(err, b.name("PARAMS"))
+		}
+		for key, values := range params {
+			dsn.Params[key] = values
+		}
+	}
+	defaults := b.Defaults
+	if defaults == nil {
+		defaults = DefaultDSNParams[baseDSNScheme(dsn.Scheme)]
+	}
+	for key, values := range defaults {
+		if _, ok := dsn.Params[key]; !ok {
+			dsn.Params[key] = append([]string{}, values...)
+		}
+	}
+
+	if err := b.validate(dsn); err != nil {
+		return nil, err
+	}
+	return dsn, nil
+}
+
+// BuildString assembles the DSN and returns both the connection string and
+// its redacted version, safe to be logged
+func (b *DSNBuilder) BuildString() (dsn, redacted string, err error) {
+	d, err := b.Build()
+	if err != nil {
+		return "", "", err
+	}
+	return d.String(), d.Redacted(), nil
+}
+
+func (b *DSNBuilder) validate(dsn *DSN) error {
+	if !dsnSchemes[dsn.Scheme] {
+		return errors.Errorf("%s: unknown driver %q", b.name("DRIVER"), dsn.Scheme)
+	}
+	scheme := baseDSNScheme(dsn.Scheme)
+	var missing []string
+	if len(dsn.Hosts) == 0 && scheme != DSNSchemeMySQL {
+		missing = append(missing, b.name("HOST"))
+	}
+	if dsn.Database == "" && scheme != DSNSchemeRedis && scheme != DSNSchemeAMQP {
+		missing = append(missing, b.name("NAME"))
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return dsn.validate()
+}
+
+// variables reads each prefixed variable or its _FILE variant
+func (b *DSNBuilder) variables(keys ...string) (map[string]string, error) {
+	lookup, readFile := b.LookupEnv, b.ReadFile
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := b.name(key)
+		value, hasValue := lookup(name)
+		filename, hasFile := lookup(name + "_FILE")
+		switch {
+		case hasValue && hasFile:
+			return nil, errors.Errorf("%s and %s_FILE are both set", name, name)
+		case hasFile:
+			content, err := readFile(filename)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s_FILE", name)
+			}
+			value = strings.TrimRight(string(content), "\r\n")
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func (b *DSNBuilder) name(key string) string {
+	if b.Prefix == "" {
+		return key
+	}
+	return b.Prefix + "_" + key
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
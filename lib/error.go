@@ -2,17 +2,62 @@ package lib
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
+// PanicHook is called with the errors of panics recovered by ErrorGroup,
+// ErrorGroupAll and Groups without OnPanic, e.g. to report them to an error
+// tracker. It must be set before starting any group
+var PanicHook func(err error)
+
+// PanicError is a panic recovered from a task. It is returned wrapped with
+// the stack trace of the panic, printed by the %+v verb
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// callSafely calls fn converting a panic into a *PanicError with the stack
+// trace, which is given to hook
+func callSafely(fn func() error, hook func(err error)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.WithStack(&PanicError{Value: r})
+			if hook != nil {
+				hook(err)
+			}
+		}
+	}()
+	return fn()
+}
+
 // ErrorGroup resolves all go routines. It fails at first error encountered
 // warning: Creating goroutines in the args will cause them to run in background
+// Panics are returned as errors, see PanicError. See ErrorGroupWithOptions
+// for tasks observing cancellation
 func ErrorGroup(ctx context.Context, args ...func() error) error {
 	g, _ := errgroup.WithContext(ctx)
 
 	for _, fn := range args {
-		g.Go(fn)
+		fn := fn
+		g.Go(func() error { return callSafely(fn, PanicHook) })
 	}
 
 	if err := g.Wait(); err != nil {
@@ -21,3 +66,327 @@ func ErrorGroup(ctx context.Context, args ...func() error) error {
 
 	return nil
 }
+
+// ErrorGroupAll runs all functions and returns a *MultiError with the error
+// of each failed one as *TaskError, or nil when all succeed
+func ErrorGroupAll(ctx context.Context, args ...func() error) error {
+	g, _ := NewGroup(ctx, GroupOptions{Mode: GroupCollectAll})
+	for _, fn := range args {
+		fn := fn
+		g.Go(func(context.Context) error { return fn() })
+	}
+	return g.Wait()
+}
+
+// GroupMode defines how a Group handles task errors
+type GroupMode int
+
+const (
+	// GroupFailFast cancels the context of the other tasks at the first
+	// error, and Wait returns it
+	GroupFailFast GroupMode = iota
+	// GroupCollectAll runs every task, and Wait returns all errors
+	GroupCollectAll
+)
+
+// GroupOptions configures a Group
+type GroupOptions struct {
+	// Limit is the maximum number of tasks running at once. Zero or negative
+	// is unlimited
+	Limit int
+	Mode  GroupMode
+	// MaxErrors caps the errors retained in GroupCollectAll mode. Zero or
+	// negative is unlimited
+	MaxErrors int
+	// OnPanic is called with the error of each panic recovered from a task,
+	// PanicHook when nil. The panic is then handled as the task error
+	OnPanic func(err error)
+	// TaskTimeout bounds the run of each task. Zero is unlimited. A task
+	// abandoned after its timeout keeps its Limit slot until it returns
+	TaskTimeout time.Duration
+	// Deadline bounds the run of all tasks. Zero is unlimited
+	Deadline time.Time
+	// OnTimeout defines how tasks exceeding TaskTimeout or Deadline are
+	// handled
+	OnTimeout TimeoutPolicy
+}
+
+// TimeoutPolicy defines how a Group handles tasks exceeding their time budget
+type TimeoutPolicy int
+
+const (
+	// TimeoutFailGroup handles the *TimeoutError as the task error
+	TimeoutFailGroup TimeoutPolicy = iota
+	// TimeoutMarkTask keeps the group running and leaves the error out of
+	// Wait. The timed out tasks are listed by TimedOut
+	TimeoutMarkTask
+)
+
+// TimeoutError is the error of a task exceeding its time budget. It matches
+// context.DeadlineExceeded and Err with errors.Is
+type TimeoutError struct {
+	// Timeout is the task timeout exceeded, zero for the group deadline
+	Timeout time.Duration
+	// Err is the error returned by the task, nil when it did not return in
+	// time
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("timed out after %s", e.Timeout)
+	}
+	return "group deadline exceeded"
+}
+
+// Is matches context.DeadlineExceeded
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// Unwrap returns the error returned by the task
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// TaskError is the error returned by a Group task, with the task index
+// given by the order of the calls to Go and the name given to GoNamed
+type TaskError struct {
+	Index int
+	Name  string
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("task %d (%s): %s", e.Index, e.Name, e.Err.Error())
+	}
+	return fmt.Sprintf("task %d: %s", e.Index, e.Err.Error())
+}
+
+// Cause returns the error returned by the task
+func (e *TaskError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error returned by the task
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Group runs tasks in goroutines with a shared context, bounded concurrency
+// and the error handling given by its mode
+type Group struct {
+	opts   GroupOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mutex    sync.Mutex
+	next     int
+	skipped  bool
+	errs     []*TaskError
+	timedOut []*TaskError
+}
+
+// NewGroup creates a Group and the context passed to its tasks, which is
+// cancelled when a task fails in GroupFailFast mode, when the Deadline is
+// exceeded or when Wait returns
+func NewGroup(ctx context.Context, opts GroupOptions) (*Group, context.Context) {
+	var cancel context.CancelFunc
+	if opts.Deadline.IsZero() {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithDeadline(ctx, opts.Deadline)
+	}
+	g := &Group{opts: opts, ctx: ctx, cancel: cancel}
+	if opts.Limit > 0 {
+		g.sem = make(chan struct{}, opts.Limit)
+	}
+	return g, ctx
+}
+
+// Go runs task in a new goroutine, blocking while Limit tasks are running.
+// In GroupFailFast mode tasks are not started once the context is done
+func (g *Group) Go(task func(ctx context.Context) error) {
+	g.GoNamed("", task)
+}
+
+// GoNamed runs task like Go, naming it in its errors, e.g. by the partner
+// it calls
+func (g *Group) GoNamed(name string, task func(ctx context.Context) error) {
+	g.mutex.Lock()
+	index := g.next
+	g.next++
+	g.mutex.Unlock()
+
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			if g.opts.Mode == GroupFailFast {
+				g.skip()
+				return
+			}
+			g.sem <- struct{}{}
+		}
+	}
+	if g.opts.Mode == GroupFailFast && g.ctx.Err() != nil {
+		g.release()
+		g.skip()
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		timedOut, abandoned, err := g.run(task)
+		if !abandoned {
+			defer g.release()
+		}
+		switch {
+		case err == nil:
+		case timedOut && g.opts.OnTimeout == TimeoutMarkTask:
+			g.markTimedOut(&TaskError{Index: index, Name: name, Err: err})
+		default:
+			g.fail(&TaskError{Index: index, Name: name, Err: err})
+		}
+	}()
+}
+
+// run calls task. With a TaskTimeout or Deadline it returns a *TimeoutError
+// as soon as the time budget is exceeded, leaving the task running in the
+// background until it observes the cancellation of its context. The Limit
+// slot of an abandoned task is released when it returns
+func (g *Group) run(task func(ctx context.Context) error) (timedOut, abandoned bool, err error) {
+	hook := g.panicHook()
+	if g.opts.TaskTimeout <= 0 && g.opts.Deadline.IsZero() {
+		return false, false, callSafely(func() error { return task(g.ctx) }, hook)
+	}
+
+	ctx, cancel := g.ctx, context.CancelFunc(func() {})
+	if g.opts.TaskTimeout > 0 {
+		ctx, cancel = context.WithTimeout(g.ctx, g.opts.TaskTimeout)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- callSafely(func() error { return task(ctx) }, hook)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			// cancelled by a failed task or the parent context
+			err = <-done
+		} else {
+			abandoned = true
+			go func() {
+				<-done
+				g.release()
+			}()
+		}
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		return false, false, err
+	}
+	timeoutErr := &TimeoutError{Err: err}
+	if g.ctx.Err() == nil {
+		timeoutErr.Timeout = g.opts.TaskTimeout
+	}
+	return true, abandoned, timeoutErr
+}
+
+// Wait blocks until all tasks return. In GroupFailFast mode it returns the
+// first *TaskError, or the context error when tasks were skipped because the
+// parent context was done. In GroupCollectAll mode it returns a *MultiError
+// of *TaskError sorted by index
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	defer g.cancel()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if len(g.errs) == 0 {
+		if g.skipped {
+			return g.ctx.Err()
+		}
+		return nil
+	}
+	if g.opts.Mode == GroupFailFast {
+		return g.errs[0]
+	}
+	sortTaskErrors(g.errs)
+	multiErr := NewMultiError(g.opts.MaxErrors)
+	for _, err := range g.errs {
+		multiErr.Append(err)
+	}
+	return multiErr
+}
+
+// TimedOut returns the errors of the tasks exceeding their time budget with
+// the TimeoutMarkTask policy, sorted by index. It is complete once Wait
+// returns
+func (g *Group) TimedOut() []*TaskError {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	timedOut := append([]*TaskError{}, g.timedOut...)
+	sortTaskErrors(timedOut)
+	return timedOut
+}
+
+func (g *Group) fail(err *TaskError) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.errs = append(g.errs, err)
+	if g.opts.Mode == GroupFailFast {
+		g.cancel()
+	}
+}
+
+func (g *Group) markTimedOut(err *TaskError) {
+	g.mutex.Lock()
+	g.timedOut = append(g.timedOut, err)
+	g.mutex.Unlock()
+}
+
+func (g *Group) panicHook() func(err error) {
+	if g.opts.OnPanic != nil {
+		return g.opts.OnPanic
+	}
+	return PanicHook
+}
+
+func (g *Group) skip() {
+	g.mutex.Lock()
+	g.skipped = true
+	g.mutex.Unlock()
+}
+
+func (g *Group) release() {
+	if g.sem != nil {
+		<-g.sem
+	}
+}
+
+func sortTaskErrors(errs []*TaskError) {
+	sort.Slice(errs, func(a, b int) bool { return errs[a].Index < errs[b].Index })
+}
+
+// ErrorGroupWithOptions runs tasks in a Group, passing them a context that
+// is cancelled when one fails in GroupFailFast mode:
+//
+//	err := ErrorGroupWithOptions(ctx, GroupOptions{Limit: 2, Mode: GroupCollectAll},
+//		func(ctx context.Context) error { return searchPartnerA(ctx, query) },
+//		func(ctx context.Context) error { return searchPartnerB(ctx, query) },
+//		func(ctx context.Context) error { return searchPartnerC(ctx, query) },
+//	)
+func ErrorGroupWithOptions(ctx context.Context, opts GroupOptions, tasks ...func(ctx context.Context) error) error {
+	g, _ := NewGroup(ctx, opts)
+	for _, task := range tasks {
+		g.Go(task)
+	}
+	return g.Wait()
+}
@@ -3,7 +3,15 @@ package lib
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestErrorGroup(t *testing.T) {
@@ -54,3 +62,259 @@ func TestErrorGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorGroupWithOptionsFailFast(t *testing.T) {
+	failure := errors.New("partner unavailable")
+	cancelled := make(chan struct{})
+
+	err := ErrorGroupWithOptions(context.Background(), GroupOptions{},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+		func(ctx context.Context) error {
+			return failure
+		},
+	)
+
+	<-cancelled
+	taskErr, ok := err.(*TaskError)
+	if assert.True(t, ok, "%T", err) {
+		assert.Equal(t, 1, taskErr.Index)
+		assert.Equal(t, failure, pkgerrors.Cause(err))
+		assert.Equal(t, "task 1: partner unavailable", err.Error())
+	}
+}
+
+func TestErrorGroupWithOptionsCollectAll(t *testing.T) {
+	err := ErrorGroupWithOptions(context.Background(), GroupOptions{Mode: GroupCollectAll},
+		func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return errors.New("timeout")
+		},
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error {
+			assert.NoError(t, ctx.Err(), "siblings are not cancelled")
+			return errors.New("not found")
+		},
+	)
+
+	multiErr, ok := err.(*MultiError)
+	if assert.True(t, ok, "%T", err) && assert.Equal(t, 2, multiErr.Len()) {
+		errs := multiErr.Errors()
+		assert.Equal(t, 0, errs[0].(*TaskError).Index)
+		assert.Equal(t, 2, errs[1].(*TaskError).Index)
+		assert.Equal(t, "2 errors occurred:\n\t* task 0: timeout\n\t* task 2: not found", err.Error())
+	}
+
+	assert.NoError(t, ErrorGroupWithOptions(context.Background(), GroupOptions{Mode: GroupCollectAll},
+		func(ctx context.Context) error { return nil }))
+}
+
+func TestErrorGroupWithOptionsLimit(t *testing.T) {
+	var running, maxRunning int32
+	tasks := make([]func(ctx context.Context) error, 20)
+	for i := range tasks {
+		tasks[i] = func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+	}
+
+	assert.NoError(t, ErrorGroupWithOptions(context.Background(), GroupOptions{Limit: 3}, tasks...))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+}
+
+func TestGroupSkipsTasksAfterCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, _ := NewGroup(ctx, GroupOptions{Limit: 1})
+
+	release := make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	cancel()
+	var started int32
+	g.Go(func(ctx context.Context) error {
+		atomic.AddInt32(&started, 1)
+		return nil
+	})
+	close(release)
+
+	assert.Equal(t, context.Canceled, g.Wait())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&started))
+}
+
+func TestErrorGroupAll(t *testing.T) {
+	err := ErrorGroupAll(context.Background(),
+		func() error { return errors.New("partner a unavailable") },
+		func() error { return nil },
+		func() error { return errors.New("partner c unavailable") },
+	)
+	if assert.Error(t, err) {
+		assert.Equal(t, "2 errors occurred:\n\t* task 0: partner a unavailable\n\t* task 2: partner c unavailable", err.Error())
+	}
+	assert.NoError(t, ErrorGroupAll(context.Background(), func() error { return nil }))
+}
+
+func TestGroupMaxErrors(t *testing.T) {
+	tasks := make([]func(ctx context.Context) error, 5)
+	for i := range tasks {
+		tasks[i] = func(ctx context.Context) error { return errors.New("failure") }
+	}
+	err := ErrorGroupWithOptions(context.Background(), GroupOptions{Mode: GroupCollectAll, MaxErrors: 2}, tasks...)
+	multiErr := err.(*MultiError)
+	assert.Equal(t, 5, multiErr.Len())
+	assert.Len(t, multiErr.Errors(), 2)
+}
+
+func TestErrorGroupRecoversPanics(t *testing.T) {
+	var reported int32
+	PanicHook = func(err error) { atomic.AddInt32(&reported, 1) }
+	defer func() { PanicHook = nil }()
+
+	err := ErrorGroup(context.Background(),
+		func() error { return nil },
+		func() error {
+			var rates map[string]float64
+			rates["BRL"] = 1
+			return nil
+		},
+	)
+
+	var panicErr *PanicError
+	if assert.True(t, errors.As(err, &panicErr)) {
+		assert.Equal(t, "panic: assignment to entry in nil map", err.Error())
+		assert.Contains(t, fmt.Sprintf("%+v", err), "TestErrorGroupRecoversPanics")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reported))
+}
+
+func TestGroupRecoversPanics(t *testing.T) {
+	var reported []error
+	cancelled := make(chan struct{})
+
+	err := ErrorGroupWithOptions(context.Background(), GroupOptions{OnPanic: func(err error) { reported = append(reported, err) }},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+		func(ctx context.Context) error { panic(io.ErrUnexpectedEOF) },
+	)
+
+	<-cancelled
+	taskErr, ok := err.(*TaskError)
+	if assert.True(t, ok, "%T", err) {
+		assert.Equal(t, 1, taskErr.Index)
+		assert.Equal(t, "task 1: panic: unexpected EOF", err.Error())
+		assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+		_, ok := pkgerrors.Cause(err).(*PanicError)
+		assert.True(t, ok)
+	}
+	if assert.Len(t, reported, 1) {
+		assert.Equal(t, taskErr.Err, reported[0])
+	}
+}
+
+func TestGroupTaskTimeout(t *testing.T) {
+	g, _ := NewGroup(context.Background(), GroupOptions{Mode: GroupCollectAll, TaskTimeout: 20 * time.Millisecond})
+	g.GoNamed("partner-a", func(ctx context.Context) error { return nil })
+	g.GoNamed("partner-b", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	g.GoNamed("partner-c", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	err := g.Wait()
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "slow tasks are not waited")
+	if assert.Error(t, err) {
+		assert.Equal(t, "2 errors occurred:\n\t* task 1 (partner-b): timed out after 20ms\n\t* task 2 (partner-c): timed out after 20ms", err.Error())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		var timeoutErr *TimeoutError
+		if assert.True(t, errors.As(err, &timeoutErr)) {
+			assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+		}
+	}
+	assert.Empty(t, g.TimedOut())
+}
+
+func TestGroupTaskTimeoutKeepsLimitSlot(t *testing.T) {
+	var mutex sync.Mutex
+	var order []string
+	g, _ := NewGroup(context.Background(), GroupOptions{Limit: 1, Mode: GroupCollectAll, TaskTimeout: 10 * time.Millisecond})
+	g.GoNamed("partner-a", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		mutex.Lock()
+		order = append(order, "a")
+		mutex.Unlock()
+		return nil
+	})
+	g.GoNamed("partner-b", func(ctx context.Context) error {
+		mutex.Lock()
+		order = append(order, "b")
+		mutex.Unlock()
+		return nil
+	})
+
+	assert.Error(t, g.Wait())
+	assert.Equal(t, []string{"a", "b"}, order, "b waits for the abandoned a to return")
+}
+
+func TestTimeoutErrorIs(t *testing.T) {
+	taskErr := errors.New("partner unavailable")
+	err := &TaskError{Err: &TimeoutError{Timeout: time.Second, Err: taskErr}}
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, taskErr))
+	assert.False(t, errors.Is(&TimeoutError{}, taskErr))
+}
+
+func TestGroupMarkTimedOutTasks(t *testing.T) {
+	var results [2]string
+	g, _ := NewGroup(context.Background(), GroupOptions{TaskTimeout: 20 * time.Millisecond, OnTimeout: TimeoutMarkTask})
+	g.GoNamed("partner-a", func(ctx context.Context) error {
+		time.Sleep(40 * time.Millisecond)
+		return nil
+	})
+	g.GoNamed("partner-b", func(ctx context.Context) error {
+		results[0] = "b"
+		return nil
+	})
+	g.GoNamed("partner-c", func(ctx context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		results[1] = "c"
+		return nil
+	})
+
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, [2]string{"b", "c"}, results)
+	timedOut := g.TimedOut()
+	if assert.Len(t, timedOut, 1) {
+		assert.Equal(t, "task 0 (partner-a): timed out after 20ms", timedOut[0].Error())
+	}
+}
+
+func TestGroupDeadline(t *testing.T) {
+	err := ErrorGroupWithOptions(context.Background(), GroupOptions{Deadline: time.Now().Add(20 * time.Millisecond)},
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	)
+	assert.EqualError(t, err, "task 1: group deadline exceeded")
+}
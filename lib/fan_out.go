@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"context"
+	"time"
+)
+
+// Waiter blocks until an operation is allowed, e.g. a rate limiter. It
+// returns an error when ctx is done first
+type Waiter interface {
+	Wait(ctx context.Context) error
+}
+
+// FanOutOptions configures FanOut and FanOutStream
+type FanOutOptions struct {
+	// Limit is the maximum number of items processed at once. Zero or
+	// negative is unlimited
+	Limit int
+	Mode  GroupMode
+	// Timeout bounds the processing of each item. Zero is unlimited
+	Timeout time.Duration
+	// Limiter, when set, is waited before processing each item
+	Limiter Waiter
+	// OnPanic is called with the error of each panic recovered from fn,
+	// PanicHook when nil
+	OnPanic func(err error)
+}
+
+// Result is the outcome of processing the input at Index
+type Result[Out any] struct {
+	Index int
+	Value Out
+	Err   error
+}
+
+// FanOut calls fn with each input concurrently and returns the outputs in
+// the order of inputs, with the zero value for the failed ones. The error
+// is the one of a Group with the same mode, *TaskError indexed as inputs:
+//
+//	hotels, err := FanOut(ctx, ids, func(ctx context.Context, id int) (*Hotel, error) {
+//		return client.GetHotel(ctx, id)
+//	}, FanOutOptions{Limit: 5, Timeout: time.Second})
+func FanOut[In, Out any](ctx context.Context, inputs []In, fn func(ctx context.Context, in In) (Out, error), opts FanOutOptions) ([]Out, error) {
+	outputs := make([]Out, len(inputs))
+	g, _ := NewGroup(ctx, opts.groupOptions())
+	for i, in := range inputs {
+		i, in := i, in
+		g.Go(func(ctx context.Context) error {
+			out, err := callItem(ctx, opts, in, fn)
+			if err != nil {
+				return err
+			}
+			outputs[i] = out
+			return nil
+		})
+	}
+	return outputs, g.Wait()
+}
+
+// FanOutStream calls fn with each input concurrently and sends the results
+// as they complete. The channel is closed once all are sent. Results are
+// dropped when ctx is done, so the channel must be drained or ctx cancelled.
+// In GroupFailFast mode inputs are not processed after the first error
+func FanOutStream[In, Out any](ctx context.Context, inputs []In, fn func(ctx context.Context, in In) (Out, error), opts FanOutOptions) <-chan Result[Out] {
+	results := make(chan Result[Out])
+	go func() {
+		defer close(results)
+		g, _ := NewGroup(ctx, opts.groupOptions())
+		for i, in := range inputs {
+			i, in := i, in
+			g.Go(func(taskCtx context.Context) error {
+				out, err := callItem(taskCtx, opts, in, fn)
+				select {
+				case results <- Result[Out]{Index: i, Value: out, Err: err}:
+				case <-ctx.Done():
+				}
+				return err
+			})
+		}
+		_ = g.Wait()
+	}()
+	return results
+}
+
+func (opts FanOutOptions) groupOptions() GroupOptions {
+	return GroupOptions{Limit: opts.Limit, Mode: opts.Mode, OnPanic: opts.OnPanic}
+}
+
+// callItem waits for the limiter and calls fn within the item timeout,
+// recovering panics so they are reported as the item error
+func callItem[In, Out any](ctx context.Context, opts FanOutOptions, in In, fn func(ctx context.Context, in In) (Out, error)) (out Out, err error) {
+	if opts.Limiter != nil {
+		if err := opts.Limiter.Wait(ctx); err != nil {
+			return out, err
+		}
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	hook := opts.OnPanic
+	if hook == nil {
+		hook = PanicHook
+	}
+	err = callSafely(func() error {
+		var err error
+		out, err = fn(ctx, in)
+		return err
+	}, hook)
+	return out, err
+}
@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingWaiter struct {
+	calls int32
+	err   error
+}
+
+func (w *countingWaiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&w.calls, 1)
+	return w.err
+}
+
+func TestFanOut(t *testing.T) {
+	inputs := []string{"1", "2", "3", "4", "5"}
+	waiter := &countingWaiter{}
+	outputs, err := FanOut(context.Background(), inputs, func(ctx context.Context, in string) (int, error) {
+		n, _ := strconv.Atoi(in)
+		time.Sleep(time.Duration(5-n) * time.Millisecond)
+		return n * 10, nil
+	}, FanOutOptions{Limit: 2, Limiter: waiter})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 20, 30, 40, 50}, outputs)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&waiter.calls))
+}
+
+func TestFanOutCollectAll(t *testing.T) {
+	outputs, err := FanOut(context.Background(), []string{"1", "x", "3", "y"}, func(ctx context.Context, in string) (int, error) {
+		return strconv.Atoi(in)
+	}, FanOutOptions{Mode: GroupCollectAll})
+
+	assert.Equal(t, []int{1, 0, 3, 0}, outputs)
+	multiErr, ok := err.(*MultiError)
+	if assert.True(t, ok, "%T", err) && assert.Equal(t, 2, multiErr.Len()) {
+		errs := multiErr.Errors()
+		assert.Equal(t, 1, errs[0].(*TaskError).Index)
+		assert.Equal(t, 3, errs[1].(*TaskError).Index)
+	}
+}
+
+func TestFanOutTimeout(t *testing.T) {
+	_, err := FanOut(context.Background(), []time.Duration{0, time.Second}, func(ctx context.Context, delay time.Duration) (bool, error) {
+		select {
+		case <-time.After(delay):
+			return true, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}, FanOutOptions{Timeout: 10 * time.Millisecond})
+
+	taskErr, ok := err.(*TaskError)
+	if assert.True(t, ok, "%T", err) {
+		assert.Equal(t, 1, taskErr.Index)
+		assert.Equal(t, context.DeadlineExceeded, taskErr.Err)
+	}
+}
+
+func TestFanOutLimiterError(t *testing.T) {
+	called := false
+	waiter := &countingWaiter{err: context.Canceled}
+	_, err := FanOut(context.Background(), []int{1}, func(ctx context.Context, in int) (int, error) {
+		called = true
+		return in, nil
+	}, FanOutOptions{Limiter: waiter})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, called)
+}
+
+func TestFanOutPanic(t *testing.T) {
+	var reported int32
+	_, err := FanOut(context.Background(), []int{0}, func(ctx context.Context, in int) (int, error) {
+		return 1 / in, nil
+	}, FanOutOptions{OnPanic: func(err error) { atomic.AddInt32(&reported, 1) }})
+
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reported))
+}
+
+func TestFanOutStream(t *testing.T) {
+	results := FanOutStream(context.Background(), []string{"1", "x", "3"}, func(ctx context.Context, in string) (int, error) {
+		return strconv.Atoi(in)
+	}, FanOutOptions{Mode: GroupCollectAll, Limit: 2})
+
+	var received []Result[int]
+	for result := range results {
+		received = append(received, result)
+	}
+	sort.Slice(received, func(a, b int) bool { return received[a].Index < received[b].Index })
+
+	if assert.Len(t, received, 3) {
+		assert.Equal(t, Result[int]{Index: 0, Value: 1}, received[0])
+		assert.Error(t, received[1].Err)
+		assert.Equal(t, Result[int]{Index: 2, Value: 3}, received[2])
+	}
+}
+
+func TestFanOutStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := FanOutStream(ctx, []int{1, 2, 3}, func(ctx context.Context, in int) (int, error) {
+		return in, nil
+	}, FanOutOptions{Mode: GroupCollectAll})
+
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,434 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker refuses a request
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// RetryPolicy configures when and how a RetryClient retries a request
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// RetryableStatusCodes are the response status codes that trigger a retry
+	RetryableStatusCodes []int
+	// RetryableMethods are the HTTP methods allowed to be retried
+	RetryableMethods []string
+	// BaseDelay is the backoff unit, doubled on each attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay between two attempts
+	MaxDelay time.Duration
+	// RespectRetryAfter uses the Retry-After header as delay when present.
+	// The response is returned without retrying when it exceeds MaxDelay
+	RespectRetryAfter bool
+	// AttemptTimeout limits each attempt. Zero means no limit
+	AttemptTimeout time.Duration
+	// Timeout limits the whole call, retries included. Zero means no limit
+	Timeout time.Duration
+}
+
+// DefaultRetryPolicy returns a policy retrying idempotent requests three
+// times on 429, 502, 503 and 504 responses
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodOptions,
+			http.MethodPut,
+			http.MethodDelete,
+			http.MethodTrace,
+		},
+		BaseDelay:         100 * time.Millisecond,
+		MaxDelay:          5 * time.Second,
+		RespectRetryAfter: true,
+	}
+}
+
+func (p RetryPolicy) isRetryableMethod(method string) bool {
+	for _, m := range p.RetryableMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the full jitter delay for the given attempt (starting at 0):
+// a random duration between zero and min(MaxDelay, BaseDelay * 2^attempt)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return FullJitter(ExponentialBackoff(p.BaseDelay, p.MaxDelay))(attempt+1, 0)
+}
+
+func jitter(ceiling time.Duration) time.Duration {
+	return time.Duration(DefaultRandom.Int63n(int64(ceiling)))
+}
+
+// parseRetryAfter reads the Retry-After header, given either in seconds or
+// as an HTTP date
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request until the open timeout elapses
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probes through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker opens after FailureThreshold consecutive failures, rejects
+// requests during OpenTimeout and then lets HalfOpenProbes requests through.
+// A successful probe closes the circuit, a failed one opens it again
+type CircuitBreaker struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenProbes   int
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	probes   int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration, halfOpenProbes int) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	if halfOpenProbes < 1 {
+		halfOpenProbes = 1
+	}
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+		HalfOpenProbes:   halfOpenProbes,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state of the circuit
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.refresh()
+	return cb.state
+}
+
+// Allow reports whether a request may be sent. It returns ErrCircuitOpen
+// when the circuit is open or all half-open probes are in flight
+func (cb *CircuitBreaker) Allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.refresh()
+
+	switch cb.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probes >= cb.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		cb.probes++
+	}
+	return nil
+}
+
+// Success records a successful request
+func (cb *CircuitBreaker) Success() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.state = CircuitClosed
+	cb.failures = 0
+	cb.probes = 0
+}
+
+// Failure records a failed request
+func (cb *CircuitBreaker) Failure() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.refresh()
+
+	if cb.state == CircuitHalfOpen {
+		cb.open()
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.FailureThreshold {
+		cb.open()
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.state = CircuitOpen
+	cb.openedAt = cb.clock()
+	cb.failures = 0
+	cb.probes = 0
+}
+
+func (cb *CircuitBreaker) refresh() {
+	if cb.state == CircuitOpen && cb.clock().Sub(cb.openedAt) >= cb.OpenTimeout {
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+	}
+}
+
+func (cb *CircuitBreaker) clock() time.Time {
+	if cb.now == nil {
+		return time.Now()
+	}
+	return cb.now()
+}
+
+// RetryClient wraps an http.Client retrying requests according to a
+// RetryPolicy and optionally guarding them with a CircuitBreaker
+type RetryClient struct {
+	Client  *http.Client
+	Policy  RetryPolicy
+	Breaker *CircuitBreaker
+}
+
+// NewRetryClient creates a RetryClient. A nil client uses http.DefaultClient
+// and a nil breaker disables the circuit breaker
+func NewRetryClient(client *http.Client, policy RetryPolicy, breaker *CircuitBreaker) *RetryClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RetryClient{Client: client, Policy: policy, Breaker: breaker}
+}
+
+// Get issues a GET to the given URL
+func (c *RetryClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req.WithContext(ctx))
+}
+
+// Do sends the request, retrying it while the policy allows. When every
+// attempt ends with a retryable status, the last response is returned
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if c.Policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Policy.Timeout)
+		resp, err := c.do(ctx, req)
+		if err != nil || resp == nil {
+			cancel()
+			return resp, err
+		}
+		resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+	return c.do(ctx, req)
+}
+
+func (c *RetryClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	getBody, err := c.bodyFactory(req)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := c.Policy.MaxAttempts
+	if maxAttempts < 1 || !c.Policy.isRetryableMethod(req.Method) {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if c.Breaker != nil {
+			if err := c.Breaker.Allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.attempt(ctx, req, getBody)
+		retryable := err != nil || c.Policy.isRetryableStatus(resp.StatusCode)
+		c.record(resp, err)
+
+		if !retryable {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, errors.Wrap(ctx.Err(), "RetryClient")
+		}
+
+		delay := c.Policy.backoff(attempt)
+		last := attempt == maxAttempts-1
+		if resp != nil && c.Policy.RespectRetryAfter {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = retryAfter
+				if c.Policy.MaxDelay > 0 && delay > c.Policy.MaxDelay {
+					last = true
+				}
+			}
+		}
+		if !last {
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+				last = true
+			}
+		}
+		if last {
+			if resp != nil {
+				return resp, nil
+			}
+			lastErr = err
+			break
+		}
+
+		if resp != nil {
+			drainBody(resp.Body)
+		}
+		lastErr = err
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Wrap(ctx.Err(), "RetryClient")
+		case <-timer.C:
+		}
+	}
+
+	return nil, errors.Wrapf(lastErr, "RetryClient: giving up after %d attempts", maxAttempts)
+}
+
+func (c *RetryClient) attempt(ctx context.Context, req *http.Request, getBody func() (io.ReadCloser, error)) (*http.Response, error) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.Policy.AttemptTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.Policy.AttemptTimeout)
+	}
+
+	r := req.WithContext(attemptCtx)
+	if getBody != nil {
+		body, err := getBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := c.Client.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *RetryClient) record(resp *http.Response, err error) {
+	if c.Breaker == nil {
+		return
+	}
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		c.Breaker.Failure()
+		return
+	}
+	c.Breaker.Success()
+}
+
+// bodyFactory returns a function producing a fresh copy of the request body
+// for each attempt, buffering it when the request cannot rewind it itself
+func (c *RetryClient) bodyFactory(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	byteArray, _, err := GetByteArrayAndBufferFromRequestBody(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "RetryClient: reading request body")
+	}
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(byteArray)), nil
+	}, nil
+}
+
+func drainBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(body, 4096))
+	body.Close()
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,255 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func fastRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryClientRetriesRetryableStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewRetryClient(server.Client(), fastRetryPolicy(), nil)
+	resp, err := client.Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientReturnsLastResponseWhenAttemptsAreExhausted(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewRetryClient(server.Client(), fastRetryPolicy(), nil)
+	resp, err := client.Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientDoesNotRetryNonIdempotentMethods(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString(`{"id":1}`))
+	resp, err := NewRetryClient(server.Client(), fastRetryPolicy(), nil).Do(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientReplaysRequestBody(t *testing.T) {
+	var calls int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, ioutil.NopCloser(bytes.NewBufferString("payload")))
+	resp, err := NewRetryClient(server.Client(), fastRetryPolicy(), nil).Do(req)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestRetryClientRespectsRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	policy := fastRetryPolicy()
+	policy.MaxDelay = 2 * time.Second
+	start := time.Now()
+	resp, err := NewRetryClient(server.Client(), policy, nil).Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, time.Since(start) >= time.Second, "waits Retry-After")
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	policy.Timeout = 200 * time.Millisecond
+	resp, err = NewRetryClient(server.Client(), policy, nil).Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "waiting Retry-After would exceed the timeout")
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientRetryAfterExceedingMaxDelay(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := NewRetryClient(server.Client(), fastRetryPolicy(), nil).Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "3600", resp.Header.Get("Retry-After"))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls), "gives up instead of waiting an hour")
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestRetryClientAttemptTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	policy := fastRetryPolicy()
+	policy.AttemptTimeout = 20 * time.Millisecond
+	resp, err := NewRetryClient(server.Client(), policy, nil).Get(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientWithGock(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("http://partner.com").
+		Get("/hotels").
+		Times(2).
+		Reply(http.StatusGatewayTimeout)
+	gock.New("http://partner.com").
+		Get("/hotels").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"name": "São Paulo"})
+
+	client := &http.Client{}
+	gock.InterceptClient(client)
+
+	resp, err := NewRetryClient(client, fastRetryPolicy(), nil).Get(context.Background(), "http://partner.com/hotels")
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, gock.IsDone())
+}
+
+func TestRetryClientCircuitBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(2, time.Minute, 1)
+	client := NewRetryClient(server.Client(), fastRetryPolicy(), breaker)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	assert.Equal(t, CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, 10*time.Second, 1)
+	breaker.now = func() time.Time { return now }
+
+	assert.Nil(t, breaker.Allow())
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.Equal(t, ErrCircuitOpen, breaker.Allow())
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.Nil(t, breaker.Allow())
+	assert.Equal(t, ErrCircuitOpen, breaker.Allow(), "only one probe is allowed")
+
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	now = now.Add(10 * time.Second)
+	assert.Nil(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.Equal(t, "closed", breaker.State().String())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt)
+		assert.True(t, delay >= 0)
+		assert.True(t, delay < 50*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(3))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	delay, ok := parseRetryAfter("120", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
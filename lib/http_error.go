@@ -0,0 +1,227 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Machine readable codes used by the HTTPError constructors
+const (
+	ErrorCodeValidation  = "validation_error"
+	ErrorCodeNotFound    = "not_found"
+	ErrorCodeConflict    = "conflict"
+	ErrorCodeRateLimited = "rate_limited"
+	ErrorCodeUpstream    = "upstream_failure"
+	ErrorCodeInternal    = "internal_error"
+)
+
+const (
+	contentTypeJSON    = "application/json; charset=utf-8"
+	contentTypeProblem = "application/problem+json; charset=utf-8"
+)
+
+// FieldError describes a problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Param is the rule parameter used to build the message, e.g. "3" for min=3
+	Param string `json:"-"`
+}
+
+// HTTPError is an error carrying everything needed to render an HTTP error
+// response: status code, machine code, message and field details
+type HTTPError struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	cause   error
+}
+
+// NewHTTPError creates an HTTPError with the given status, code and message
+func NewHTTPError(status int, code, message string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+// NewValidationError creates a 422 HTTPError with field details
+func NewValidationError(message string, fields ...FieldError) *HTTPError {
+	e := NewHTTPError(http.StatusUnprocessableEntity, ErrorCodeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// NewNotFoundError creates a 404 HTTPError
+func NewNotFoundError(message string) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, ErrorCodeNotFound, message)
+}
+
+// NewConflictError creates a 409 HTTPError
+func NewConflictError(message string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, ErrorCodeConflict, message)
+}
+
+// NewUpstreamError creates a 502 HTTPError wrapping the failure of a partner
+// or internal dependency
+func NewUpstreamError(cause error, message string) *HTTPError {
+	return NewHTTPError(http.StatusBadGateway, ErrorCodeUpstream, message).WithCause(cause)
+}
+
+// WithCause sets the underlying error. The cause is never rendered to clients
+func (e *HTTPError) WithCause(cause error) *HTTPError {
+	e.cause = cause
+	return e
+}
+
+// WithField appends a field detail
+func (e *HTTPError) WithField(field, code, message string) *HTTPError {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+	return e
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			fields = append(fields, f.Field+": "+f.Message)
+		}
+		msg += " (" + strings.Join(fields, "; ") + ")"
+	}
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Cause returns the underlying error, as expected by errors.Cause
+func (e *HTTPError) Cause() error {
+	return e.cause
+}
+
+// Unwrap returns the underlying error
+func (e *HTTPError) Unwrap() error {
+	return e.cause
+}
+
+// AsHTTPError looks for an HTTPError in the chain of causes of err,
+// following both Cause and Unwrap
+func AsHTTPError(err error) (*HTTPError, bool) {
+	for err != nil {
+		if httpErr, ok := err.(*HTTPError); ok {
+			return httpErr, true
+		}
+		switch e := err.(type) {
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// ErrorEnvelope is the JSON body rendered by WriteError
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody is the content of an ErrorEnvelope
+type ErrorBody struct {
+	Status  int          `json:"status"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// Problem is the RFC 7807 body rendered by WriteProblem
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// ErrorWriter renders errors as HTTP responses. Errors which are not an
+// HTTPError are rendered as a 500 without leaking their message
+type ErrorWriter struct {
+	// Problem switches the output to RFC 7807 application/problem+json
+	Problem bool
+	// ProblemTypeBaseURL prefixes the machine code to build the problem type.
+	// When empty the type is "about:blank"
+	ProblemTypeBaseURL string
+}
+
+// DefaultErrorWriter is used by WriteError and WriteProblem
+var DefaultErrorWriter = ErrorWriter{}
+
+// WriteError renders err as a JSON error envelope
+func WriteError(w http.ResponseWriter, err error) {
+	ew := DefaultErrorWriter
+	ew.Problem = false
+	ew.Write(w, err)
+}
+
+// WriteProblem renders err as an RFC 7807 problem+json document
+func WriteProblem(w http.ResponseWriter, err error) {
+	ew := DefaultErrorWriter
+	ew.Problem = true
+	ew.Write(w, err)
+}
+
+// Write renders err according to the writer mode
+func (ew ErrorWriter) Write(w http.ResponseWriter, err error) {
+	httpErr, ok := AsHTTPError(err)
+	if !ok {
+		httpErr = NewHTTPError(http.StatusInternalServerError, ErrorCodeInternal,
+			http.StatusText(http.StatusInternalServerError))
+	}
+	if httpErr.Status < 100 || httpErr.Status > 599 {
+		// WriteHeader panics on invalid status codes
+		internal := *httpErr
+		internal.Status = http.StatusInternalServerError
+		httpErr = &internal
+	}
+
+	var body interface{}
+	contentType := contentTypeJSON
+	if ew.Problem {
+		contentType = contentTypeProblem
+		body = ew.problem(httpErr)
+	} else {
+		body = ErrorEnvelope{Error: ErrorBody{
+			Status:  httpErr.Status,
+			Code:    httpErr.Code,
+			Message: httpErr.Message,
+			Fields:  httpErr.Fields,
+		}}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(httpErr.Status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (ew ErrorWriter) problem(e *HTTPError) Problem {
+	problemType := "about:blank"
+	if ew.ProblemTypeBaseURL != "" && e.Code != "" {
+		problemType = strings.TrimRight(ew.ProblemTypeBaseURL, "/") + "/" + e.Code
+	}
+	return Problem{
+		Type:   problemType,
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Detail: e.Message,
+		Code:   e.Code,
+		Errors: e.Fields,
+	}
+}
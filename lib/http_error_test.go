@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorConstructors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *HTTPError
+		wantStatus int
+		wantCode   string
+	}{
+		{"validation", NewValidationError("invalid input"), http.StatusUnprocessableEntity, ErrorCodeValidation},
+		{"not found", NewNotFoundError("hotel not found"), http.StatusNotFound, ErrorCodeNotFound},
+		{"conflict", NewConflictError("already booked"), http.StatusConflict, ErrorCodeConflict},
+		{"upstream", NewUpstreamError(errors.New("timeout"), "partner failed"), http.StatusBadGateway, ErrorCodeUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, tt.err.Status)
+			assert.Equal(t, tt.wantCode, tt.err.Code)
+		})
+	}
+}
+
+func TestHTTPErrorCause(t *testing.T) {
+	root := errors.New("connection refused")
+	err := NewUpstreamError(errors.Wrap(root, "calling partner"), "partner failed")
+
+	assert.Equal(t, root, errors.Cause(err))
+	assert.Equal(t, "502 upstream_failure: partner failed: calling partner: connection refused", err.Error())
+
+	wrapped := errors.Wrap(NewNotFoundError("hotel not found"), "loading hotel")
+	httpErr, ok := AsHTTPError(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, httpErr.Status)
+
+	stdWrapped := fmt.Errorf("loading hotel: %w", errors.Wrap(NewConflictError("booking changed"), "booking"))
+	httpErr, ok = AsHTTPError(stdWrapped)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, httpErr.Status)
+
+	_, ok = AsHTTPError(errors.New("plain"))
+	assert.False(t, ok)
+
+	_, ok = AsHTTPError(nil)
+	assert.False(t, ok)
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := NewValidationError("invalid input").WithField("checkin", "date", "must be a date")
+
+	WriteError(w, errors.Wrap(err, "binding"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var body ErrorEnvelope
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, ErrorEnvelope{Error: ErrorBody{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    ErrorCodeValidation,
+		Message: "invalid input",
+		Fields:  []FieldError{{Field: "checkin", Code: "date", Message: "must be a date"}},
+	}}, body)
+}
+
+func TestWriteErrorHidesUnknownErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteError(w, errors.New("dial tcp 10.0.0.1:3306: secret details"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":{"status":500,"code":"internal_error","message":"Internal Server Error"}}`, w.Body.String())
+}
+
+func TestWriteErrorInvalidStatus(t *testing.T) {
+	for _, status := range []int{0, 99, 600} {
+		w := httptest.NewRecorder()
+
+		WriteError(w, NewHTTPError(status, "broken", "broken status"))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code, status)
+		assert.JSONEq(t, `{"error":{"status":500,"code":"broken","message":"broken status"}}`, w.Body.String())
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteProblem(w, NewConflictError("room already booked"))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"room already booked","code":"conflict"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	ErrorWriter{Problem: true, ProblemTypeBaseURL: "https://api.hurb.com/errors/"}.Write(w, NewNotFoundError("hotel not found"))
+
+	var problem Problem
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "https://api.hurb.com/errors/not_found", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+}
@@ -0,0 +1,323 @@
+package lib
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidUUID is the cause of the errors returned by ParseUUID
+	ErrInvalidUUID = errors.New("invalid UUID")
+	// ErrInvalidULID is the cause of the errors returned by ParseULID
+	ErrInvalidULID = errors.New("invalid ULID")
+	// ErrInvalidConfirmationCode is the cause of the errors returned by
+	// ParseConfirmationCode
+	ErrInvalidConfirmationCode = errors.New("invalid confirmation code")
+)
+
+// idRandReader is the source of the random bits of generated IDs
+var idRandReader io.Reader = cryptorand.Reader
+
+// UUID is an RFC 9562 universally unique identifier
+type UUID [16]byte
+
+// NewUUIDv4 returns a random UUID
+func NewUUIDv4() (UUID, error) {
+	var u UUID
+	if _, err := io.ReadFull(idRandReader, u[:]); err != nil {
+		return u, errors.Wrap(err, "generating UUID")
+	}
+	u.setVersion(4)
+	return u, nil
+}
+
+// NewUUIDv7 returns a UUID starting with the current Unix time in
+// milliseconds, so UUIDs created in different milliseconds sort by time
+func NewUUIDv7() (UUID, error) {
+	var u UUID
+	if _, err := io.ReadFull(idRandReader, u[6:]); err != nil {
+		return u, errors.Wrap(err, "generating UUID")
+	}
+	putMillis(u[:6], time.Now())
+	u.setVersion(7)
+	return u, nil
+}
+
+// MustNewUUIDv4 is like NewUUIDv4 but panics on error
+func MustNewUUIDv4() UUID {
+	u, err := NewUUIDv4()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// MustNewUUIDv7 is like NewUUIDv7 but panics on error
+func MustNewUUIDv7() UUID {
+	u, err := NewUUIDv7()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func (u *UUID) setVersion(version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// ParseUUID reads a UUID in the canonical form, with or without hyphens, in
+// any case
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	digits := s
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, errors.Wrapf(ErrInvalidUUID, "%q", s)
+		}
+		digits = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(digits) != 32 {
+		return u, errors.Wrapf(ErrInvalidUUID, "%q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, errors.Wrapf(ErrInvalidUUID, "%q", s)
+	}
+	return u, nil
+}
+
+// IsUUID reports whether s is a valid UUID
+func IsUUID(s string) bool {
+	_, err := ParseUUID(s)
+	return err == nil
+}
+
+// Version returns the UUID version, e.g. 4 or 7
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time returns the creation time of a version 7 UUID, the zero time for
+// other versions
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	return millisTime(u[:6])
+}
+
+// IsZero reports whether u is the nil UUID
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// String returns the canonical lowercase hyphenated form
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[:8], u[:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// MarshalText encodes u in the canonical form
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText decodes a UUID read by ParseUUID
+func (u *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// crockfordAlphabet is the Crockford base32 alphabet used by ULIDs
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID is a lexicographically sortable identifier made of a millisecond
+// timestamp and 80 random bits, encoded as 26 Crockford base32 characters
+type ULID [16]byte
+
+// NewULID returns a ULID with the current time
+func NewULID() (ULID, error) {
+	var id ULID
+	if _, err := io.ReadFull(idRandReader, id[6:]); err != nil {
+		return id, errors.Wrap(err, "generating ULID")
+	}
+	putMillis(id[:6], time.Now())
+	return id, nil
+}
+
+// MustNewULID is like NewULID but panics on error
+func MustNewULID() ULID {
+	id, err := NewULID()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ParseULID reads a ULID in any case, accepting I and L for 1 and O for 0
+func ParseULID(s string) (ULID, error) {
+	var id ULID
+	if len(s) != 26 {
+		return id, errors.Wrapf(ErrInvalidULID, "%q", s)
+	}
+	// 26 characters of 5 bits hold 130 bits, the first 2 must be zero
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		value := crockfordValue(s[i])
+		if value < 0 || i == 0 && value > 7 {
+			return id, errors.Wrapf(ErrInvalidULID, "%q", s)
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(value)
+	}
+	binary.BigEndian.PutUint64(id[:8], hi)
+	binary.BigEndian.PutUint64(id[8:], lo)
+	return id, nil
+}
+
+// IsULID reports whether s is a valid ULID
+func IsULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+func crockfordValue(c byte) int {
+	switch c {
+	case 'i', 'I', 'l', 'L':
+		return 1
+	case 'o', 'O':
+		return 0
+	}
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	return strings.IndexByte(crockfordAlphabet, c)
+}
+
+// Time returns the creation time of the ULID
+func (id ULID) Time() time.Time {
+	return millisTime(id[:6])
+}
+
+// String returns the uppercase Crockford base32 encoding
+func (id ULID) String() string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var b [26]byte
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+// MarshalText encodes id as a string
+func (id ULID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes a ULID read by ParseULID
+func (id *ULID) UnmarshalText(text []byte) error {
+	parsed, err := ParseULID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+func millisTime(b []byte) time.Time {
+	var ms int64
+	for _, c := range b {
+		ms = ms<<8 | int64(c)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// ConfirmationCodeAlphabet are the symbols of confirmation codes, without
+// the ambiguous 0, 1, I and O
+const ConfirmationCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// NewConfirmationCode returns length random symbols followed by a Luhn mod
+// 32 check symbol, which detects any mistyped symbol and most swaps of
+// adjacent ones, e.g. "K7QX3MRD" plus its check symbol
+func NewConfirmationCode(length int) (string, error) {
+	if length <= 0 {
+		return "", errors.Errorf("invalid confirmation code length %d", length)
+	}
+	random := make([]byte, length)
+	if _, err := io.ReadFull(idRandReader, random); err != nil {
+		return "", errors.Wrap(err, "generating confirmation code")
+	}
+	code := make([]byte, length, length+1)
+	for i, b := range random {
+		// 256 is a multiple of 32, so the symbols are uniform
+		code[i] = ConfirmationCodeAlphabet[int(b)%len(ConfirmationCodeAlphabet)]
+	}
+	return string(append(code, confirmationCheckSymbol(string(code)))), nil
+}
+
+// ParseConfirmationCode validates a code typed by a user, ignoring case,
+// spaces and hyphens, and returns it normalized
+func ParseConfirmationCode(s string) (string, error) {
+	code := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	if len(code) < 2 {
+		return "", errors.Wrapf(ErrInvalidConfirmationCode, "%q", s)
+	}
+	for i := 0; i < len(code); i++ {
+		if strings.IndexByte(ConfirmationCodeAlphabet, code[i]) < 0 {
+			return "", errors.Wrapf(ErrInvalidConfirmationCode, "%q", s)
+		}
+	}
+	last := len(code) - 1
+	if confirmationCheckSymbol(code[:last]) != code[last] {
+		return "", errors.Wrapf(ErrInvalidConfirmationCode, "%q: wrong check symbol", s)
+	}
+	return code, nil
+}
+
+// IsConfirmationCode reports whether s is a valid confirmation code
+func IsConfirmationCode(s string) bool {
+	_, err := ParseConfirmationCode(s)
+	return err == nil
+}
+
+// confirmationCheckSymbol computes the Luhn mod N check symbol of code
+func confirmationCheckSymbol(code string) byte {
+	n := len(ConfirmationCodeAlphabet)
+	factor, sum := 2, 0
+	for i := len(code) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(ConfirmationCodeAlphabet, code[i])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+	return ConfirmationCodeAlphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,141 @@
+package lib
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUID(t *testing.T) {
+	v4 := MustNewUUIDv4()
+	assert.Equal(t, 4, v4.Version())
+	assert.Equal(t, byte(0x80), v4[8]&0xc0, "RFC 9562 variant")
+	assert.True(t, v4.Time().IsZero())
+	assert.NotEqual(t, v4, MustNewUUIDv4())
+
+	before := time.Now().Truncate(time.Millisecond)
+	v7 := MustNewUUIDv7()
+	assert.Equal(t, 7, v7.Version())
+	assert.False(t, v7.Time().Before(before))
+	assert.False(t, v7.Time().After(time.Now()))
+
+	s := v7.String()
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, s)
+	parsed, err := ParseUUID(strings.ToUpper(s))
+	assert.NoError(t, err)
+	assert.Equal(t, v7, parsed)
+}
+
+func TestUUIDv7Sorts(t *testing.T) {
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, MustNewUUIDv7().String())
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.True(t, sort.StringsAreSorted(ids), "%v", ids)
+}
+
+func TestParseUUID(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d479", true},
+		{"F47AC10B-58CC-4372-A567-0E02B2C3D479", true},
+		{"f47ac10b58cc4372a5670e02b2c3d479", true},
+		{"00000000-0000-0000-0000-000000000000", true},
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d47", false},
+		{"f47ac10b+58cc-4372-a567-0e02b2c3d479", false},
+		{"g47ac10b-58cc-4372-a567-0e02b2c3d479", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			u, err := ParseUUID(tt.in)
+			assert.Equal(t, tt.valid, IsUUID(tt.in))
+			if tt.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, strings.ToLower(strings.Replace(tt.in, "-", "", -1)), strings.Replace(u.String(), "-", "", -1))
+			} else {
+				assert.Equal(t, ErrInvalidUUID, errors.Cause(err))
+			}
+		})
+	}
+	assert.True(t, UUID{}.IsZero())
+}
+
+func TestULID(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	id := MustNewULID()
+	assert.False(t, id.Time().Before(before))
+
+	s := id.String()
+	assert.Regexp(t, `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`, s)
+	parsed, err := ParseULID(strings.ToLower(s))
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	max, err := ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	assert.NoError(t, err)
+	assert.Equal(t, ULID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, max)
+
+	known, err := ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1469922850259), known.Time().UnixNano()/int64(time.Millisecond))
+	alias, _ := ParseULID("OlARZ3NDEKTSV4RRFFQ69G5FAV")
+	assert.Equal(t, known, alias)
+
+	for _, invalid := range []string{"", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "01ARZ3NDEKTSV4RRFFQ69G5FA"} {
+		_, err := ParseULID(invalid)
+		assert.Equal(t, ErrInvalidULID, errors.Cause(err), invalid)
+		assert.False(t, IsULID(invalid))
+	}
+}
+
+func TestIDsJSON(t *testing.T) {
+	type booking struct {
+		ID             UUID `json:"id"`
+		IdempotencyKey ULID `json:"idempotencyKey"`
+	}
+	in := booking{ID: MustNewUUIDv4(), IdempotencyKey: MustNewULID()}
+	body, err := json.Marshal(in)
+	assert.NoError(t, err)
+
+	var out booking
+	assert.NoError(t, json.Unmarshal(body, &out))
+	assert.Equal(t, in, out)
+	assert.Error(t, json.Unmarshal([]byte(`{"id": "not-a-uuid"}`), &out))
+}
+
+func TestConfirmationCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := NewConfirmationCode(8)
+		assert.NoError(t, err)
+		assert.Len(t, code, 9)
+		assert.Equal(t, "", strings.Trim(code, ConfirmationCodeAlphabet))
+
+		parsed, err := ParseConfirmationCode(strings.ToLower(code[:4] + "-" + code[4:]))
+		assert.NoError(t, err)
+		assert.Equal(t, code, parsed)
+	}
+
+	_, err := NewConfirmationCode(0)
+	assert.Error(t, err)
+}
+
+func TestParseConfirmationCode(t *testing.T) {
+	code, _ := NewConfirmationCode(6)
+	mistyped := []byte(code)
+	mistyped[2] = ConfirmationCodeAlphabet[(strings.IndexByte(ConfirmationCodeAlphabet, mistyped[2])+1)%len(ConfirmationCodeAlphabet)]
+
+	for _, invalid := range []string{"", "A", "K7QX-3MR0", "K7QX-3MRI", string(mistyped)} {
+		_, err := ParseConfirmationCode(invalid)
+		assert.Equal(t, ErrInvalidConfirmationCode, errors.Cause(err), invalid)
+		assert.False(t, IsConfirmationCode(invalid))
+	}
+}
@@ -0,0 +1,238 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// JoinEmptyPolicy defines how JoinWithOptions handles nil values and empty
+// strings
+type JoinEmptyPolicy int
+
+const (
+	// JoinSkipEmpty drops nil values and empty strings
+	JoinSkipEmpty JoinEmptyPolicy = iota
+	// JoinSkipNil drops nil values and keeps empty strings
+	JoinSkipNil
+	// JoinKeepEmpty keeps empty strings and renders nil values as NilValue
+	JoinKeepEmpty
+)
+
+// JoinQuoting defines how JoinWithOptions quotes each element
+type JoinQuoting int
+
+const (
+	// JoinQuoteNone writes elements as they are
+	JoinQuoteNone JoinQuoting = iota
+	// JoinQuoteCSV double quotes elements containing the separator, quotes or
+	// line breaks, doubling inner quotes as in RFC 4180
+	JoinQuoteCSV
+	// JoinQuoteSQL single quotes strings escaping quotes, backslashes and
+	// control characters as MySQL does, keeps numbers and booleans unquoted
+	// and renders nil as NULL, for SQL IN lists. Prefer placeholders for
+	// untrusted input
+	JoinQuoteSQL
+)
+
+// JoinOptions configures JoinWithOptions
+type JoinOptions struct {
+	// Separator written between elements
+	Separator string
+	// Depth is how many levels of nested slices, arrays and maps are
+	// flattened. Zero writes containers with their default format and a
+	// negative value flattens any depth. Pointers are always dereferenced
+	Depth int
+	// Empty is the nil and empty string policy
+	Empty JoinEmptyPolicy
+	// NilValue is written for nil values kept by JoinKeepEmpty
+	NilValue string
+	// Formatters converts values of specific types to string, taking
+	// precedence over every other conversion
+	Formatters map[reflect.Type]func(interface{}) string
+	// Quoting applied to each element
+	Quoting JoinQuoting
+}
+
+// DefaultJoinOptions returns the options used by Join: one level of
+// flattening and nil values and empty strings skipped
+func DefaultJoinOptions(sep string) JoinOptions {
+	return JoinOptions{Separator: sep, Depth: 1, Empty: JoinSkipEmpty}
+}
+
+// JoinWithOptions converts args to strings and concatenates them:
+//
+//	opts := JoinOptions{Separator: ", ", Depth: -1, Empty: JoinKeepEmpty, Quoting: JoinQuoteSQL}
+//	JoinWithOptions(opts, []interface{}{1, "O'Hara", nil}) // 1, 'O''Hara', NULL
+//
+// Values implementing error or Stringer are written with Error and String.
+// Maps are flattened in key order so the output is deterministic
+func JoinWithOptions(opts JoinOptions, args ...interface{}) string {
+	j := joiner{opts: opts}
+	for _, arg := range args {
+		j.add(reflect.ValueOf(arg), 0)
+	}
+	return strings.Join(j.elements, opts.Separator)
+}
+
+type joiner struct {
+	opts     JoinOptions
+	elements []string
+}
+
+func (j *joiner) add(value reflect.Value, depth int) {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			value = reflect.Value{}
+			break
+		}
+		if _, ok := j.opts.Formatters[value.Type()]; ok || implementsStringer(value.Type()) {
+			break
+		}
+		value = value.Elem()
+	}
+
+	if !value.IsValid() {
+		switch {
+		case j.opts.Empty == JoinKeepEmpty && j.opts.Quoting == JoinQuoteSQL:
+			j.elements = append(j.elements, "NULL")
+		case j.opts.Empty == JoinKeepEmpty:
+			j.elements = append(j.elements, j.opts.NilValue)
+		}
+		return
+	}
+
+	if j.canFlatten(value, depth) {
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				j.add(value.Index(i), depth+1)
+			}
+			return
+		case reflect.Map:
+			for _, key := range sortedMapKeys(value) {
+				j.add(value.MapIndex(key), depth+1)
+			}
+			return
+		}
+	}
+
+	s := j.format(value)
+	if s == "" && j.opts.Empty == JoinSkipEmpty {
+		return
+	}
+	j.elements = append(j.elements, j.quote(value, s))
+}
+
+func (j *joiner) canFlatten(value reflect.Value, depth int) bool {
+	if _, ok := j.opts.Formatters[value.Type()]; ok || implementsStringer(value.Type()) {
+		return false
+	}
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return j.opts.Depth < 0 || depth < j.opts.Depth
+}
+
+func (j *joiner) format(value reflect.Value) string {
+	if formatter, ok := j.opts.Formatters[value.Type()]; ok {
+		return formatter(value.Interface())
+	}
+	if !value.CanInterface() {
+		return fmt.Sprint(value)
+	}
+
+	switch v := value.Interface().(type) {
+	case error:
+		return v.Error()
+	case Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprint(value.Interface())
+	}
+	if s, err := cast.ToStringE(value.Interface()); err == nil {
+		return s
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+var sqlQuoteReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `''`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+func (j *joiner) quote(value reflect.Value, s string) string {
+	switch j.opts.Quoting {
+	case JoinQuoteCSV:
+		if strings.ContainsAny(s, "\"\r\n") || (j.opts.Separator != "" && strings.Contains(s, j.opts.Separator)) {
+			return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+		}
+	case JoinQuoteSQL:
+		if isNumericOrBoolKind(value.Kind()) && !implementsStringer(value.Type()) {
+			return s
+		}
+		return "'" + sqlQuoteReplacer.Replace(s) + "'"
+	}
+	return s
+}
+
+var stringerType = reflect.TypeOf((*Stringer)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func implementsStringer(t reflect.Type) bool {
+	return t.Implements(stringerType) || t.Implements(errorType)
+}
+
+func isNumericOrBoolKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// sortedMapKeys returns the keys of a map ordered numerically when they are
+// numbers and by their string representation otherwise
+func sortedMapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(a, b int) bool {
+		ka, kb := keys[a], keys[b]
+		switch {
+		case isIntKind(ka.Kind()) && isIntKind(kb.Kind()):
+			return ka.Int() < kb.Int()
+		case isUintKind(ka.Kind()) && isUintKind(kb.Kind()):
+			return ka.Uint() < kb.Uint()
+		case isFloatKind(ka.Kind()) && isFloatKind(kb.Kind()):
+			return ka.Float() < kb.Float()
+		}
+		return fmt.Sprint(ka.Interface()) < fmt.Sprint(kb.Interface())
+	})
+	return keys
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type joinRoom struct {
+	ID int
+}
+
+func (r joinRoom) String() string {
+	return "room-" + strconv.Itoa(r.ID)
+}
+
+type joinCode string
+
+func TestJoinWithOptions(t *testing.T) {
+	str := "foo"
+	var nilStr *string
+
+	tests := []struct {
+		name string
+		opts JoinOptions
+		args []interface{}
+		want string
+	}{
+		{
+			name: "default options match Join",
+			opts: DefaultJoinOptions("_"),
+			args: []interface{}{[]string{"foo", "bar", ""}, nil, 123},
+			want: "foo_bar_123",
+		},
+		{
+			name: "trailing empty values do not leave separators",
+			opts: DefaultJoinOptions(","),
+			args: []interface{}{"a", []string{"b", ""}, ""},
+			want: "a,b",
+		},
+		{
+			name: "one level of flattening",
+			opts: DefaultJoinOptions(","),
+			args: []interface{}{[][]int{{1, 2}, {3}}},
+			want: "[1 2],[3]",
+		},
+		{
+			name: "recursive flattening",
+			opts: JoinOptions{Separator: ",", Depth: -1},
+			args: []interface{}{[][]int{{1, 2}, {3}}, &[]int{4}},
+			want: "1,2,3,4",
+		},
+		{
+			name: "no flattening",
+			opts: JoinOptions{Separator: ";"},
+			args: []interface{}{[]int{1, 2}, 3},
+			want: "[1 2];3",
+		},
+		{
+			name: "keep empty values",
+			opts: JoinOptions{Separator: ",", Empty: JoinKeepEmpty, NilValue: "null"},
+			args: []interface{}{"a", "", nil, nilStr, &str},
+			want: "a,,null,null,foo",
+		},
+		{
+			name: "skip nil only",
+			opts: JoinOptions{Separator: ",", Empty: JoinSkipNil},
+			args: []interface{}{"a", "", nil, "b"},
+			want: "a,,b",
+		},
+		{
+			name: "stringer and error",
+			opts: DefaultJoinOptions(", "),
+			args: []interface{}{joinRoom{ID: 1}, &joinRoom{ID: 2}, []joinRoom{{ID: 3}}, errors.New("oops")},
+			want: "room-1, room-2, room-3, oops",
+		},
+		{
+			name: "maps in key order",
+			opts: JoinOptions{Separator: ",", Depth: 1},
+			args: []interface{}{map[int]string{10: "ten", 2: "two", 1: "one"}, map[string]int{"b": 2, "a": 1}},
+			want: "one,two,ten,1,2",
+		},
+		{
+			name: "custom formatters",
+			opts: JoinOptions{Separator: "|", Depth: 1, Formatters: map[reflect.Type]func(interface{}) string{
+				reflect.TypeOf(time.Time{}): func(v interface{}) string { return v.(time.Time).Format(DatePatternYYYYMMDD) },
+				reflect.TypeOf(false):       func(v interface{}) string { return ParseBoolToString(v.(bool)) },
+			}},
+			args: []interface{}{time.Date(2020, 10, 22, 10, 0, 0, 0, time.UTC), []bool{true, false}},
+			want: "2020-10-22|1|0",
+		},
+		{
+			name: "named types and bytes",
+			opts: DefaultJoinOptions(","),
+			args: []interface{}{joinCode("HU"), []byte("raw")},
+			want: "HU,raw",
+		},
+		{
+			name: "csv quoting",
+			opts: JoinOptions{Separator: ",", Depth: 1, Quoting: JoinQuoteCSV},
+			args: []interface{}{"São Paulo, SP", `say "hi"`, "plain", 42},
+			want: `"São Paulo, SP","say ""hi""",plain,42`,
+		},
+		{
+			name: "sql in list",
+			opts: JoinOptions{Separator: ", ", Depth: -1, Empty: JoinKeepEmpty, Quoting: JoinQuoteSQL},
+			args: []interface{}{[]interface{}{1, "O'Hara", nil, 2.5, true}},
+			want: "1, 'O''Hara', NULL, 2.5, true",
+		},
+		{
+			name: "sql escapes backslashes",
+			opts: JoinOptions{Separator: ", ", Depth: 1, Quoting: JoinQuoteSQL},
+			args: []interface{}{`\' OR 1=1 -- `, "a\nb\x00\x1a"},
+			want: `'\\'' OR 1=1 -- ', 'a\nb\0\Z'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, JoinWithOptions(tt.opts, tt.args...))
+		})
+	}
+}
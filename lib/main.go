@@ -6,7 +6,6 @@ import (
 	"io"
 	"io/ioutil"
 	"math"
-	"math/rand"
 	"net/http"
 	"net/http/httputil"
 	"reflect"
@@ -15,13 +14,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/fatih/structs"
 	"github.com/pkg/errors"
-	"github.com/spf13/cast"
 )
 
 const (
 	// HTTPStatusUnprocessableEntity REQUIRE THEM TO DOCUMENT THIS CONST
+	//
 	// Deprecated: use http.StatusUnprocessableEntity instead
 	HTTPStatusUnprocessableEntity = 422
 
@@ -109,6 +107,38 @@ func ToInt64Slice(stringSlice []string) (int64Slice []int64) {
 	return int64Slice
 }
 
+// ToIntSliceE converts each string to int, like ToIntSlice, returning a
+// *MultiError with the index of each invalid string
+func ToIntSliceE(stringSlice []string) ([]int, error) {
+	intSlice := make([]int, 0, len(stringSlice))
+	errs := NewMultiError(0)
+	for index, s := range stringSlice {
+		i, err := ParseStringToInt(s)
+		if err != nil {
+			errs.Append(errors.Wrapf(err, "index %d", index))
+			continue
+		}
+		intSlice = append(intSlice, i)
+	}
+	return intSlice, errs.ErrorOrNil()
+}
+
+// ToInt64SliceE converts each string to int64, like ToInt64Slice, returning
+// a *MultiError with the index of each invalid string
+func ToInt64SliceE(stringSlice []string) ([]int64, error) {
+	int64Slice := make([]int64, 0, len(stringSlice))
+	errs := NewMultiError(0)
+	for index, s := range stringSlice {
+		i, err := ParseStringToInt64(s)
+		if err != nil {
+			errs.Append(errors.Wrapf(err, "index %d", index))
+			continue
+		}
+		int64Slice = append(int64Slice, i)
+	}
+	return int64Slice, errs.ErrorOrNil()
+}
+
 // StringToStringSlice REQUIRE THEM TO DOCUMENT THIS FUNCTION
 func StringToStringSlice(s string) []string {
 	stringSlice := []string{}
@@ -276,26 +306,15 @@ func GetByteArrayAndBufferFromRequestBody(body io.ReadCloser) ([]byte, *bytes.Bu
 	return byteArray, buffer, nil
 }
 
-// GetOnlyNumbers REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// GetOnlyNumbers returns a copy of s keeping only the digits 0-9
 func GetOnlyNumbers(s *string) *string {
-	return GetOnlyNumbersOrSpecial(s, "")
+	return digitsFilter.FilterPtr(s)
 }
 
-// GetOnlyNumbersOrSpecial REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// GetOnlyNumbersOrSpecial returns a copy of s keeping only the digits 0-9 and
+// the characters of sp
 func GetOnlyNumbersOrSpecial(s *string, sp string) *string {
-	if s == nil {
-		return s
-	}
-	specials := ""
-	if len(sp) > 0 {
-		for _, item := range strings.Split(sp, "") {
-			specials = specials + `\` + item
-		}
-	}
-	pattern := fmt.Sprintf(`[^%s0-9]`, specials)
-	r := regexp.MustCompile(pattern)
-	result := r.ReplaceAllString(*s, "")
-	return &result
+	return digitsOrSpecialFilter(sp).FilterPtr(s)
 }
 
 // GetStringBodyHTTPRequest REQUIRE THEM TO DOCUMENT THIS FUNCTION
@@ -408,23 +427,19 @@ type Stringer interface {
 	String() string
 }
 
-// IsArray REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// IsArray reports whether arg, or the value it points to, is a slice or an
+// array
 func IsArray(arg interface{}) bool {
-	if arg == nil {
-		return false
-	}
-	return reflect.TypeOf(arg).Kind() == reflect.Slice
+	kind := indirectKind(arg)
+	return kind == reflect.Slice || kind == reflect.Array
 }
 
-// IsString REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// IsString reports whether arg, or the value it points to, is a string
 func IsString(arg interface{}) bool {
-	if arg == nil {
-		return false
-	}
-	return reflect.TypeOf(arg).Kind() == reflect.String
+	return indirectKind(arg) == reflect.String
 }
 
-// IsPointer REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// IsPointer reports whether arg is a pointer, nil or not
 func IsPointer(arg interface{}) bool {
 	if arg == nil {
 		return false
@@ -432,46 +447,12 @@ func IsPointer(arg interface{}) bool {
 	return reflect.TypeOf(arg).Kind() == reflect.Ptr
 }
 
-// Join REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// Join converts args to strings and concatenates them with sep, flattening
+// slices one level and skipping nil values and empty strings. Byte slices
+// are written as text, Join(",", []byte("ab")) giving "ab" where it used to
+// give "97,98". See JoinWithOptions for more control over the output
 func Join(sep string, args ...interface{}) string {
-	var buf bytes.Buffer
-	var elements []interface{}
-
-	for _, arg := range args {
-		if arg == nil {
-			continue
-		}
-
-		if IsArray(arg) {
-			valueArg := reflect.ValueOf(arg)
-			for j := 0; j < valueArg.Len(); j++ {
-				elements = append(elements, valueArg.Index(j).Interface())
-			}
-		} else if IsString(arg) {
-			if len(arg.(string)) > 0 {
-				elements = append(elements, arg)
-			}
-		} else if IsPointer(arg) {
-			valueArg := reflect.ValueOf(arg)
-			if valueArg.Elem().IsValid() {
-				elements = append(elements, valueArg.Elem())
-			}
-		} else {
-			elements = append(elements, arg)
-		}
-	}
-
-	for i, arg := range elements {
-		if str := cast.ToString(arg); len(str) > 0 {
-			buf.WriteString(str)
-
-			if i < len(elements)-1 {
-				buf.WriteString(sep)
-			}
-		}
-	}
-
-	return buf.String()
+	return JoinWithOptions(DefaultJoinOptions(sep), args...)
 }
 
 // BeginningOfToday REQUIRE THEM TO DOCUMENT THIS FUNCTION
@@ -486,13 +467,16 @@ func BeginningOfTodayIn(loc *time.Location) time.Time {
 	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 }
 
-// DSN2MAP REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// DSN2MAP splits a go-sql-driver/mysql DSN into user, passwd, net, addr,
+// dbname and params
+//
+// Deprecated: use ParseDSN, which also validates the DSN and supports other
+// databases
 func DSN2MAP(dsn string) map[string]string {
-	re := regexp.MustCompile("^(?:(?P<user>.*?)(?::(?P<passwd>.*))?@)?(?:(?P<net>[^\\(]*)(?:\\((?P<addr>[^\\)]*)\\))?)?\\/(?P<dbname>.*?)(?:\\?(?P<params>[^\\?]*))?$")
-	match := re.FindStringSubmatch(dsn)
+	match := dsnMySQLRegexp.FindStringSubmatch(dsn)
 
 	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
+	for i, name := range dsnMySQLRegexp.SubexpNames() {
 		if len(name) > 0 && i < len(match) {
 			result[name] = match[i]
 		}
@@ -500,15 +484,22 @@ func DSN2MAP(dsn string) map[string]string {
 	return result
 }
 
-// DSN2Publishable REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// DSN2Publishable returns the DSN without the password, formatted from the
+// matches of DSN2MAP when it cannot be parsed
+//
+// Deprecated: use DSN.Redacted
 func DSN2Publishable(dsn string) string {
-	dsnMap := DSN2MAP(dsn)
-	return fmt.Sprintf("%s@%s(%s)/%s?%s",
-		dsnMap["user"],
-		dsnMap["net"],
-		dsnMap["addr"],
-		dsnMap["dbname"],
-		dsnMap["params"])
+	parsed, err := ParseDSN(dsn)
+	if err != nil {
+		dsnMap := DSN2MAP(dsn)
+		return fmt.Sprintf("%s@%s(%s)/%s?%s",
+			dsnMap["user"],
+			dsnMap["net"],
+			dsnMap["addr"],
+			dsnMap["dbname"],
+			dsnMap["params"])
+	}
+	return parsed.Redacted()
 }
 
 // Round REQUIRE THEM TO DOCUMENT THIS FUNCTION
@@ -517,34 +508,32 @@ func Round(value float64, precision int) float64 {
 	return math.Ceil(value*exponential) / exponential
 }
 
-// RandomInt REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// RandomInt returns a value in [bottom, top), bottom when the range is empty
+//
+// Deprecated: use DefaultRandom.IntRange, or IntBetween to include top
 func RandomInt(bottom, top int) int {
-	rand.Seed(time.Now().UTC().UnixNano())
-	return rand.Intn(top-bottom) + bottom
+	return DefaultRandom.IntRange(bottom, top)
 }
 
-// Truncate REQUIRE THEM TO DOCUMENT THIS FUNCTION
+// Truncate keeps the first i characters of s, then trims it and removes line
+// breaks and 4-space runs
+//
+// Deprecated: use TruncateText, which supports ellipsis, word boundaries and
+// explicit whitespace normalization
 func Truncate(s string, i int) (r string) {
-	r = s
-	if len(s) > i {
-		r = s[:i]
-	}
+	r = TruncateText(s, i, TruncateOptions{})
 	r = strings.TrimSpace(r)
 	r = strings.Replace(r, "\n", "", -1)
 	r = strings.Replace(r, "    ", "", -1)
 	return
 }
 
-// Fill merges data from struct instance to another
-// By @titpetric suggested in https://scene-si.org/2016/06/01/golang-tips-and-tricks
+// Fill merges data from struct instance to another by matching field names.
+// Conversion errors are ignored
+//
+// Deprecated: use Copy or CopyWithOptions, which report conversion errors
 func Fill(dest interface{}, src interface{}) {
-	mSrc := structs.Map(src)
-	mDest := structs.Map(dest)
-	for key, val := range mSrc {
-		if _, ok := mDest[key]; ok {
-			structs.New(dest).Field(key).Set(val)
-		}
-	}
+	_ = CopyWithOptions(dest, src, CopyOptions{})
 }
 
 // ParseStringToFloat64 parse the string to float64
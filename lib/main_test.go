@@ -257,6 +257,28 @@ func TestToInt64Slice(t *testing.T) {
 	assert.Equal(t, int64(852369), actual[1])
 }
 
+func TestToIntSliceE(t *testing.T) {
+	actual, err := ToIntSliceE([]string{"6549", "a", "8523", "1.5"})
+
+	assert.Equal(t, []int{6549, 8523}, actual)
+	if assert.Error(t, err) {
+		assert.Equal(t, 2, err.(*MultiError).Len())
+		assert.Contains(t, err.Error(), `index 1: strconv.Atoi: parsing "a": invalid syntax`)
+		assert.Contains(t, err.Error(), `index 3: strconv.Atoi: parsing "1.5": invalid syntax`)
+	}
+
+	actual, err = ToIntSliceE([]string{"1", "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, actual)
+}
+
+func TestToInt64SliceE(t *testing.T) {
+	actual, err := ToInt64SliceE([]string{"654987", "852369", "a"})
+
+	assert.Equal(t, []int64{654987, 852369}, actual)
+	assert.EqualError(t, err, `index 2: strconv.ParseInt: parsing "a": invalid syntax`)
+}
+
 func TestStringToStringSlice(t *testing.T) {
 	actual := StringToStringSlice("[foo,123,bar,,456,a1b2,,,]")
 
@@ -735,6 +757,9 @@ func TestJoin(t *testing.T) {
 
 	actual = Join(", ", 654321987, nil, 654.654, "", pStr, &str)
 	assert.Equal(t, `654321987, 654.654, foo`, actual)
+
+	actual = Join(",", []byte("ab"), "c")
+	assert.Equal(t, `ab,c`, actual, "byte slices are written as text")
 }
 
 func TestBeginningOfToday(t *testing.T) {
@@ -792,16 +817,37 @@ func TestDSN2Publishable(t *testing.T) {
 	expected := "MyUser@tcp(MyDatabaseHost.net:3306)/MyDatabaseName?param1=1&param2=2"
 
 	assert.Equal(t, expected, DSN2Publishable(dsn))
+	assert.Equal(t, "@()/?", DSN2Publishable("not a dsn"))
+	assert.Equal(t, "MyUser@tcp(host:3306)/MyDatabaseName?password=redacted", DSN2Publishable("MyUser@tcp(host:3306)/MyDatabaseName?password=secret"))
 }
 
 func TestGetByteArrayAndBufferFromRequestBody(t *testing.T) { t.Skip("Implement this test") }
-func TestGetOnlyNumbers(t *testing.T)                       { t.Skip("Implement this test") }
-func TestGetOnlyNumbersOrSpecial(t *testing.T)              { t.Skip("Implement this test") }
 func TestParseStringToBool(t *testing.T)                    { t.Skip("Implement this test") }
 func TestParseStringToInt(t *testing.T)                     { t.Skip("Implement this test") }
 func TestParseStringToInt64(t *testing.T)                   { t.Skip("Implement this test") }
 func TestToStringSlice64(t *testing.T)                      { t.Skip("Implement this test") }
 
+func TestGetOnlyNumbers(t *testing.T) {
+	s := "Hotel nº 123 - São Paulo"
+	assert.Equal(t, "123", *GetOnlyNumbers(&s))
+
+	s = "0123456789"
+	assert.Equal(t, s, *GetOnlyNumbers(&s))
+
+	s = ""
+	assert.Equal(t, "", *GetOnlyNumbers(&s))
+}
+
+func TestGetOnlyNumbersOrSpecial(t *testing.T) {
+	s := "R$ 1.234,56 / nº 7"
+	assert.Equal(t, "1.234,567", *GetOnlyNumbersOrSpecial(&s, ".,"))
+	assert.Equal(t, "$1.234,567", *GetOnlyNumbersOrSpecial(&s, "$.,"))
+	assert.Equal(t, "123456º7", *GetOnlyNumbersOrSpecial(&s, "º"), "multibyte specials are supported")
+
+	s = `a[1]\2^-]b`
+	assert.Equal(t, `[1]\2^-]`, *GetOnlyNumbersOrSpecial(&s, `[]\^-`))
+}
+
 func TestRound(t *testing.T) {
 	assert.Equal(t, 1.2, Round(float64(1.2), 2))
 	assert.Equal(t, 1.23, Round(float64(1.23), 2))
@@ -830,6 +876,12 @@ func TestRandomInt(t *testing.T) {
 	assert.NotEqual(t, c, d)
 	assert.NotEqual(t, c, e)
 	assert.NotEqual(t, d, e)
+
+	for i := 0; i < 100; i++ {
+		n := RandomInt(1, 3)
+		assert.True(t, n >= 1 && n < 3, "%d", n)
+	}
+	assert.Equal(t, 7, RandomInt(7, 7))
 }
 
 func TestTruncate(t *testing.T) {
@@ -863,6 +915,21 @@ func TestFill(t *testing.T) {
 	assert.Equal(t, "Bobby", a.Name)
 }
 
+func TestFillConvertsTypes(t *testing.T) {
+	dst := struct {
+		ID   int
+		Name string
+	}{ID: 1}
+	src := struct {
+		ID   string
+		Name string
+	}{ID: "2", Name: "Bobby"}
+
+	Fill(&dst, src)
+	assert.Equal(t, 2, dst.ID)
+	assert.Equal(t, "Bobby", dst.Name)
+}
+
 func TestParseStringToFloat64(t *testing.T) {
 	type args struct {
 		s string
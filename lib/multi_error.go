@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiError aggregates errors. It is safe for concurrent use and works
+// with errors.Is and errors.As, which match any of the aggregated errors
+type MultiError struct {
+	mutex   sync.Mutex
+	max     int
+	errs    []error
+	dropped int
+}
+
+// NewMultiError creates a MultiError retaining up to max errors. Errors
+// appended beyond max are only counted. Zero or negative is unlimited
+func NewMultiError(max int) *MultiError {
+	return &MultiError{max: max}
+}
+
+// Append adds the non nil errors. The errors of appended MultiErrors are
+// added one by one
+func (m *MultiError) Append(errs ...error) *MultiError {
+	var flat []error
+	dropped := 0
+	for _, err := range errs {
+		other, isMulti := err.(*MultiError)
+		switch {
+		case isMulti && other != nil && other != m:
+			nested, nestedDropped := other.snapshot()
+			flat = append(flat, nested...)
+			dropped += nestedDropped
+		case !isMulti && err != nil:
+			flat = append(flat, err)
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, err := range flat {
+		m.appendLocked(err)
+	}
+	m.dropped += dropped
+	return m
+}
+
+func (m *MultiError) appendLocked(err error) {
+	if m.max > 0 && len(m.errs) >= m.max {
+		m.dropped++
+		return
+	}
+	m.errs = append(m.errs, err)
+}
+
+func (m *MultiError) snapshot() ([]error, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]error{}, m.errs...), m.dropped
+}
+
+// Errors returns a copy of the retained errors
+func (m *MultiError) Errors() []error {
+	errs, _ := m.snapshot()
+	return errs
+}
+
+// Len returns the number of errors appended, retained or not
+func (m *MultiError) Len() int {
+	errs, dropped := m.snapshot()
+	return len(errs) + dropped
+}
+
+// Dropped returns the number of errors appended beyond the limit
+func (m *MultiError) Dropped() int {
+	_, dropped := m.snapshot()
+	return dropped
+}
+
+// ErrorOrNil returns nil when no error was appended, so a MultiError can be
+// returned as error without the nil interface pitfall
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error lists the errors, one per line:
+//
+//	2 errors occurred:
+//		* task 0: timeout
+//		* task 2: not found
+func (m *MultiError) Error() string {
+	errs, dropped := m.snapshot()
+	total := len(errs) + dropped
+	if total == 1 && dropped == 0 {
+		return errs[0].Error()
+	}
+
+	var b strings.Builder
+	if total == 1 {
+		b.WriteString("1 error occurred:")
+	} else {
+		fmt.Fprintf(&b, "%d errors occurred:", total)
+	}
+	for _, err := range errs {
+		b.WriteString("\n\t* ")
+		b.WriteString(err.Error())
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "\n\t* and %d more", dropped)
+	}
+	return b.String()
+}
+
+// MarshalJSON renders the errors as {"errors": ["..."], "dropped": 0}
+func (m *MultiError) MarshalJSON() ([]byte, error) {
+	errs, dropped := m.snapshot()
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return json.Marshal(struct {
+		Errors  []string `json:"errors"`
+		Dropped int      `json:"dropped"`
+	}{messages, dropped})
+}
+
+// Is reports whether any retained error matches target
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.Errors() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first retained error matching target
+func (m *MultiError) As(target interface{}) bool {
+	for _, err := range m.Errors() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
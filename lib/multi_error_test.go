@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiError(t *testing.T) {
+	m := NewMultiError(0)
+	assert.Nil(t, m.ErrorOrNil())
+
+	m.Append(nil, errors.New("timeout"), nil)
+	assert.Equal(t, 1, m.Len())
+	assert.Equal(t, "timeout", m.Error())
+
+	m.Append(pkgerrors.Wrap(io.EOF, "reading rates"))
+	assert.Equal(t, "2 errors occurred:\n\t* timeout\n\t* reading rates: EOF", m.Error())
+	assert.Equal(t, m, m.ErrorOrNil())
+
+	var nilMulti *MultiError
+	assert.Nil(t, nilMulti.ErrorOrNil())
+	m.Append(nilMulti, m)
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestMultiErrorLimit(t *testing.T) {
+	m := NewMultiError(2)
+	m.Append(errors.New("a"), errors.New("b"), errors.New("c"), errors.New("d"))
+
+	assert.Equal(t, 4, m.Len())
+	assert.Equal(t, 2, m.Dropped())
+	assert.Len(t, m.Errors(), 2)
+	assert.Equal(t, "4 errors occurred:\n\t* a\n\t* b\n\t* and 2 more", m.Error())
+
+	nested := NewMultiError(0).Append(errors.New("e"), m)
+	assert.Equal(t, 5, nested.Len())
+	assert.Equal(t, 2, nested.Dropped())
+}
+
+func TestMultiErrorIsAs(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "/run/secrets/db", Err: os.ErrNotExist}
+	var err error = NewMultiError(0).Append(
+		errors.New("timeout"),
+		pkgerrors.Wrap(pathErr, "reading secret"),
+		&TaskError{Index: 3, Err: io.ErrUnexpectedEOF},
+	)
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.False(t, errors.Is(err, io.EOF))
+
+	var target *os.PathError
+	if assert.True(t, errors.As(err, &target)) {
+		assert.Equal(t, "/run/secrets/db", target.Path)
+	}
+	var taskErr *TaskError
+	if assert.True(t, errors.As(err, &taskErr)) {
+		assert.Equal(t, 3, taskErr.Index)
+	}
+}
+
+func TestMultiErrorJSON(t *testing.T) {
+	m := NewMultiError(1).Append(errors.New("invalid cpf"), errors.New("invalid cep"))
+	body, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errors": ["invalid cpf"], "dropped": 1}`, string(body))
+}
+
+func TestMultiErrorConcurrentAppend(t *testing.T) {
+	m := NewMultiError(50)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Append(errors.New("failure"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 100, m.Len())
+	assert.Equal(t, 50, m.Dropped())
+}
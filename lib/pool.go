@@ -0,0 +1,236 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrPoolFull is returned by Submit when the queue is full with the
+	// BackpressureError policy
+	ErrPoolFull = errors.New("pool queue is full")
+	// ErrPoolClosed is returned by Submit after Shutdown
+	ErrPoolClosed = errors.New("pool is closed")
+)
+
+// Backpressure defines how Submit handles a full queue
+type Backpressure int
+
+const (
+	// BackpressureBlock waits for room in the queue
+	BackpressureBlock Backpressure = iota
+	// BackpressureDrop discards the job, counted by Dropped
+	BackpressureDrop
+	// BackpressureError returns ErrPoolFull
+	BackpressureError
+)
+
+// PoolOptions configures a Pool
+type PoolOptions struct {
+	// Workers is the number of jobs run at once, 1 when zero or negative
+	Workers int
+	// QueueSize is the number of jobs waiting for a worker. With zero jobs
+	// are only handed to idle workers
+	QueueSize    int
+	Backpressure Backpressure
+	// OnError is called with the error of each failed job
+	OnError func(err error)
+	// OnPanic is called with the error of each panic recovered from a job,
+	// PanicHook when nil. The panic is then handled as the job error
+	OnPanic func(err error)
+	// OnQueueDepth is called with the number of queued jobs when it changes
+	OnQueueDepth func(depth int)
+	// OnJobDone is called after each job with the time it waited in the
+	// queue and the time it ran
+	OnJobDone func(wait, run time.Duration, err error)
+}
+
+// Pool runs jobs submitted to a bounded queue with a fixed number of
+// long-lived workers, e.g. for background price refreshes:
+//
+//	pool := NewPool(ctx, PoolOptions{Workers: 4, QueueSize: 100})
+//	err := pool.Submit(ctx, func(ctx context.Context) error {
+//		return refreshPrices(ctx, hotelID)
+//	})
+//	...
+//	err = pool.Shutdown(shutdownCtx)
+type Pool struct {
+	dropped  int64 // first for 64-bit alignment of atomic operations
+	opts     PoolOptions
+	ctx      context.Context
+	cancel   context.CancelFunc
+	queue    chan poolJob
+	closing  chan struct{} // closed by Shutdown to stop Submit
+	draining chan struct{} // closed once no Submit can queue jobs
+	workers  sync.WaitGroup
+
+	// mutex guards closed and the registration of submitters, which are
+	// waited before draining
+	mutex      sync.Mutex
+	closed     bool
+	submitters sync.WaitGroup
+}
+
+type poolJob struct {
+	run      func(ctx context.Context) error
+	queuedAt time.Time
+}
+
+// NewPool starts a Pool whose jobs receive a context derived from ctx,
+// cancelled when ctx is done or when Shutdown gives up draining the queue
+func NewPool(ctx context.Context, opts PoolOptions) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool{
+		opts:     opts,
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    make(chan poolJob, opts.QueueSize),
+		closing:  make(chan struct{}),
+		draining: make(chan struct{}),
+	}
+	p.workers.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues job applying the Backpressure policy when the queue is full.
+// With BackpressureBlock it returns the ctx error when ctx is done first, and
+// ErrPoolClosed when Shutdown is called first
+func (p *Pool) Submit(ctx context.Context, job func(ctx context.Context) error) error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return ErrPoolClosed
+	}
+	p.submitters.Add(1)
+	p.mutex.Unlock()
+	defer p.submitters.Done()
+
+	item := poolJob{run: job, queuedAt: time.Now()}
+	select {
+	case p.queue <- item:
+		p.reportQueueDepth()
+		return nil
+	default:
+	}
+
+	switch p.opts.Backpressure {
+	case BackpressureDrop:
+		atomic.AddInt64(&p.dropped, 1)
+		return nil
+	case BackpressureError:
+		return ErrPoolFull
+	}
+	select {
+	case p.queue <- item:
+		p.reportQueueDepth()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.closing:
+		return ErrPoolClosed
+	case <-p.ctx.Done():
+		return ErrPoolClosed
+	}
+}
+
+// QueueDepth returns the number of jobs waiting for a worker
+func (p *Pool) QueueDepth() int {
+	return len(p.queue)
+}
+
+// Dropped returns the number of jobs discarded with BackpressureDrop
+func (p *Pool) Dropped() int {
+	return int(atomic.LoadInt64(&p.dropped))
+}
+
+// Shutdown stops accepting jobs and waits for the queued and running ones.
+// When ctx is done first it cancels the context of the jobs, discards the
+// queued ones and returns the ctx error without waiting further
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	close(p.closing)
+	p.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.submitters.Wait()
+		close(p.draining)
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for {
+		select {
+		case job := <-p.queue:
+			if p.ctx.Err() != nil {
+				return
+			}
+			p.run(job)
+		case <-p.ctx.Done():
+			return
+		case <-p.draining:
+			for {
+				select {
+				case job := <-p.queue:
+					if p.ctx.Err() != nil {
+						return
+					}
+					p.run(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Pool) run(job poolJob) {
+	p.reportQueueDepth()
+	start := time.Now()
+	hook := p.opts.OnPanic
+	if hook == nil {
+		hook = PanicHook
+	}
+	err := callSafely(func() error { return job.run(p.ctx) }, hook)
+	if err != nil && p.opts.OnError != nil {
+		p.opts.OnError(err)
+	}
+	if p.opts.OnJobDone != nil {
+		p.opts.OnJobDone(start.Sub(job.queuedAt), time.Since(start), err)
+	}
+}
+
+func (p *Pool) reportQueueDepth() {
+	if p.opts.OnQueueDepth != nil {
+		p.opts.OnQueueDepth(len(p.queue))
+	}
+}
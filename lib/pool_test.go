@@ -0,0 +1,161 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	var mutex sync.Mutex
+	var failures []error
+	var done int32
+	pool := NewPool(context.Background(), PoolOptions{
+		Workers:   3,
+		QueueSize: 10,
+		OnError: func(err error) {
+			mutex.Lock()
+			failures = append(failures, err)
+			mutex.Unlock()
+		},
+		OnJobDone: func(wait, run time.Duration, err error) {
+			assert.True(t, wait >= 0 && run >= 0)
+			atomic.AddInt32(&done, 1)
+		},
+	})
+
+	var ran int32
+	for i := 0; i < 20; i++ {
+		i := i
+		assert.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			if i == 7 {
+				return errors.New("price refresh failed")
+			}
+			return nil
+		}))
+	}
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	assert.Equal(t, int32(20), atomic.LoadInt32(&ran))
+	assert.Equal(t, int32(20), atomic.LoadInt32(&done))
+	assert.Equal(t, []error{errors.New("price refresh failed")}, failures)
+	assert.Equal(t, ErrPoolClosed, pool.Submit(context.Background(), func(ctx context.Context) error { return nil }))
+	assert.Equal(t, ErrPoolClosed, pool.Shutdown(context.Background()))
+}
+
+func TestPoolBackpressure(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Backpressure
+		err     error
+		dropped int
+	}{
+		{"drop", BackpressureDrop, nil, 1},
+		{"error", BackpressureError, ErrPoolFull, 0},
+		{"block", BackpressureBlock, context.DeadlineExceeded, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			pool := NewPool(context.Background(), PoolOptions{QueueSize: 1, Backpressure: tt.policy})
+			started := make(chan struct{})
+			assert.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+				close(started)
+				<-release
+				return nil
+			}))
+			<-started
+			assert.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { return nil }))
+			assert.Equal(t, 1, pool.QueueDepth())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := pool.Submit(ctx, func(ctx context.Context) error { return nil })
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.dropped, pool.Dropped())
+
+			close(release)
+			assert.NoError(t, pool.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestPoolShutdownTimeout(t *testing.T) {
+	pool := NewPool(context.Background(), PoolOptions{QueueSize: 5})
+	var ran int32
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, pool.Shutdown(ctx))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran), "queued jobs are discarded")
+}
+
+func TestPoolPanicAndQueueDepth(t *testing.T) {
+	var depths []int
+	var mutex sync.Mutex
+	var jobErr error
+	pool := NewPool(context.Background(), PoolOptions{
+		QueueSize: 2,
+		OnPanic:   func(err error) {},
+		OnQueueDepth: func(depth int) {
+			mutex.Lock()
+			depths = append(depths, depth)
+			mutex.Unlock()
+		},
+		OnError: func(err error) { jobErr = err },
+	})
+
+	assert.NoError(t, pool.Submit(context.Background(), func(ctx context.Context) error { panic("cache warmup") }))
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	var panicErr *PanicError
+	if assert.True(t, errors.As(jobErr, &panicErr)) {
+		assert.Equal(t, "cache warmup", panicErr.Value)
+	}
+	mutex.Lock()
+	assert.Len(t, depths, 2)
+	mutex.Unlock()
+}
+
+func TestPoolShutdownWithBlockedSubmitter(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := NewPool(context.Background(), PoolOptions{QueueSize: 1})
+	blocking := func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	assert.NoError(t, pool.Submit(context.Background(), blocking))
+	assert.NoError(t, pool.Submit(context.Background(), blocking))
+
+	submitted := make(chan error)
+	go func() {
+		submitted <- pool.Submit(context.Background(), blocking)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, pool.Shutdown(ctx))
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "Shutdown respects its deadline")
+	assert.Equal(t, ErrPoolClosed, <-submitted)
+	assert.Equal(t, ErrPoolClosed, pool.Submit(context.Background(), blocking))
+}
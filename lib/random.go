@@ -0,0 +1,259 @@
+package lib
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alphabets for Random.String
+const (
+	AlphabetDigits       = "0123456789"
+	AlphabetLowercase    = "abcdefghijklmnopqrstuvwxyz"
+	AlphabetUppercase    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetAlphanumeric = AlphabetDigits + AlphabetUppercase + AlphabetLowercase
+	AlphabetHex          = "0123456789abcdef"
+)
+
+var (
+	// DefaultRandom is a time seeded Random, not suitable for secrets
+	DefaultRandom = NewSeededRandom(time.Now().UnixNano())
+	// CryptoRandom is a Random backed by crypto/rand, for tokens and secrets
+	CryptoRandom = NewRandom(cryptoSource{})
+)
+
+// Random generates random values from a rand.Source. It is safe for
+// concurrent use. Bounds given in reverse order are swapped
+type Random struct {
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+// NewRandom creates a Random reading source, which does not need to be safe
+// for concurrent use
+func NewRandom(source rand.Source) *Random {
+	return &Random{rand: rand.New(source)}
+}
+
+// NewSeededRandom creates a Random generating the same values for the same
+// seed, e.g. in tests
+func NewSeededRandom(seed int64) *Random {
+	return NewRandom(rand.NewSource(seed))
+}
+
+// cryptoSource is a rand.Source reading crypto/rand
+type cryptoSource struct{}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func (cryptoSource) Seed(int64) {}
+
+// Int63n returns a value in [0, n), zero when n is not positive
+func (r *Random) Int63n(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rand.Int63n(n)
+}
+
+// offset returns a value in [0, span], span being up to the whole uint64
+// range
+func (r *Random) offset(span uint64) uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if span < math.MaxInt64 {
+		return uint64(r.rand.Int63n(int64(span) + 1))
+	}
+	// rejection sampling, accepting more than half of the values
+	for {
+		if n := r.rand.Uint64(); n <= span {
+			return n
+		}
+	}
+}
+
+// IntBetween returns a value in [min, max]
+func (r *Random) IntBetween(min, max int) int {
+	if max < min {
+		min, max = max, min
+	}
+	// the difference of the two's complement values is the span even when
+	// it overflows int
+	return min + int(r.offset(uint64(max)-uint64(min)))
+}
+
+// IntRange returns a value in [min, max), min when the range is empty
+func (r *Random) IntRange(min, max int) int {
+	if max < min {
+		min, max = max, min
+	}
+	if max == min {
+		return min
+	}
+	return min + int(r.offset(uint64(max)-uint64(min)-1))
+}
+
+// Float64 returns a value in [0, 1)
+func (r *Random) Float64() float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rand.Float64()
+}
+
+// DurationBetween returns a duration in [min, max]
+func (r *Random) DurationBetween(min, max time.Duration) time.Duration {
+	if max < min {
+		min, max = max, min
+	}
+	return min + time.Duration(r.offset(uint64(max)-uint64(min)))
+}
+
+// Shuffle randomizes the order of n elements using swap
+func (r *Random) Shuffle(n int, swap func(i, j int)) {
+	if n < 2 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rand.Shuffle(n, swap)
+}
+
+// Sample returns k distinct indexes in [0, n) in random order, all of them
+// when k is greater than n
+func (r *Random) Sample(n, k int) []int {
+	if k > n {
+		k = n
+	}
+	if k <= 0 {
+		return []int{}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// partial Fisher-Yates over a sparse permutation
+	swapped := make(map[int]int, k)
+	indexes := make([]int, k)
+	for i := 0; i < k; i++ {
+		j := i + r.rand.Intn(n-i)
+		vi, ok := swapped[i]
+		if !ok {
+			vi = i
+		}
+		vj, ok := swapped[j]
+		if !ok {
+			vj = j
+		}
+		indexes[i] = vj
+		swapped[j] = vi
+	}
+	return indexes
+}
+
+// WeightedIndex returns an index chosen with a probability proportional to
+// its weight, -1 when no weight is positive. Negative weights count as zero
+func (r *Random) WeightedIndex(weights []float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		if weight > 0 {
+			total += weight
+		}
+	}
+	if total <= 0 {
+		return -1
+	}
+	target := r.Float64() * total
+	last := -1
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		if target < weight {
+			return i
+		}
+		target -= weight
+		last = i
+	}
+	return last
+}
+
+// String returns length characters of alphabet, AlphabetAlphanumeric when
+// empty
+func (r *Random) String(length int, alphabet string) string {
+	if alphabet == "" {
+		alphabet = AlphabetAlphanumeric
+	}
+	symbols := []rune(alphabet)
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		b.WriteRune(symbols[r.Int63n(int64(len(symbols)))])
+	}
+	return b.String()
+}
+
+// HexID returns size random bytes hex encoded
+func (r *Random) HexID(size int) string {
+	if size <= 0 {
+		return ""
+	}
+	b := make([]byte, size)
+	r.mutex.Lock()
+	r.rand.Read(b)
+	r.mutex.Unlock()
+	return hex.EncodeToString(b)
+}
+
+// ShuffleSlice randomizes the order of s in place
+func ShuffleSlice[T any](r *Random, s []T) {
+	r.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+}
+
+// Choose returns a random element of s, false when s is empty
+func Choose[T any](r *Random, s []T) (T, bool) {
+	var choice T
+	if len(s) == 0 {
+		return choice, false
+	}
+	return s[r.Int63n(int64(len(s)))], true
+}
+
+// WeightedChoice returns an element of s chosen with a probability
+// proportional to its weight, false when no weight is positive
+func WeightedChoice[T any](r *Random, s []T, weight func(T) float64) (T, bool) {
+	weights := make([]float64, len(s))
+	for i, item := range s {
+		weights[i] = weight(item)
+	}
+	var choice T
+	i := r.WeightedIndex(weights)
+	if i < 0 {
+		return choice, false
+	}
+	return s[i], true
+}
+
+// SampleSlice returns k distinct elements of s in random order, without
+// replacement
+func SampleSlice[T any](r *Random, s []T, k int) []T {
+	indexes := r.Sample(len(s), k)
+	sample := make([]T, len(indexes))
+	for i, index := range indexes {
+		sample[i] = s[index]
+	}
+	return sample
+}
@@ -0,0 +1,155 @@
+package lib
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomSeeded(t *testing.T) {
+	a, b := NewSeededRandom(42), NewSeededRandom(42)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, a.IntBetween(0, 1000), b.IntBetween(0, 1000))
+	}
+	assert.Equal(t, a.String(16, ""), b.String(16, ""))
+}
+
+func TestRandomRanges(t *testing.T) {
+	r := NewSeededRandom(1)
+	seen := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		n := r.IntBetween(1, 3)
+		assert.True(t, n >= 1 && n <= 3, "%d", n)
+		seen[n] = true
+
+		n = r.IntRange(1, 3)
+		assert.True(t, n >= 1 && n < 3, "%d", n)
+
+		d := r.DurationBetween(time.Second, 2*time.Second)
+		assert.True(t, d >= time.Second && d <= 2*time.Second, "%s", d)
+	}
+	assert.Len(t, seen, 3, "max is included")
+
+	assert.Equal(t, 5, r.IntBetween(5, 5))
+	assert.Equal(t, 5, r.IntRange(5, 5))
+	n := r.IntBetween(10, -10)
+	assert.True(t, n >= -10 && n <= 10, "%d", n)
+	assert.Equal(t, int64(0), r.Int63n(0))
+}
+
+func TestRandomFullRanges(t *testing.T) {
+	r := NewSeededRandom(11)
+	var negative, positive, negativeDuration, positiveDuration bool
+	for i := 0; i < 100; i++ {
+		n := r.IntBetween(math.MinInt, math.MaxInt)
+		negative = negative || n < 0
+		positive = positive || n > 0
+
+		n = r.IntRange(math.MinInt, math.MaxInt)
+		assert.True(t, n < math.MaxInt)
+
+		d := r.DurationBetween(-time.Duration(math.MaxInt64), time.Duration(math.MaxInt64))
+		negativeDuration = negativeDuration || d < 0
+		positiveDuration = positiveDuration || d > 0
+
+		n = r.IntBetween(math.MaxInt-1, math.MaxInt)
+		assert.True(t, n >= math.MaxInt-1, "%d", n)
+	}
+	assert.True(t, negative && positive, "values are spread over the whole range")
+	assert.True(t, negativeDuration && positiveDuration, "durations are spread over the whole range")
+	assert.Equal(t, math.MinInt, r.IntBetween(math.MinInt, math.MinInt))
+}
+
+func TestRandomSample(t *testing.T) {
+	r := NewSeededRandom(7)
+	for _, k := range []int{0, 1, 5, 10, 20} {
+		indexes := r.Sample(10, k)
+		want := k
+		if want > 10 {
+			want = 10
+		}
+		assert.Len(t, indexes, want)
+		sorted := append([]int{}, indexes...)
+		sort.Ints(sorted)
+		for i := 1; i < len(sorted); i++ {
+			assert.NotEqual(t, sorted[i-1], sorted[i], "no replacement")
+		}
+		for _, index := range indexes {
+			assert.True(t, index >= 0 && index < 10)
+		}
+	}
+
+	hotels := []string{"rio", "paris", "lisbon", "tokyo"}
+	sample := SampleSlice(r, hotels, 4)
+	sort.Strings(sample)
+	assert.Equal(t, []string{"lisbon", "paris", "rio", "tokyo"}, sample)
+}
+
+func TestRandomShuffle(t *testing.T) {
+	r := NewSeededRandom(3)
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	ShuffleSlice(r, items)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, items)
+	assert.NotEqual(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, items)
+
+	choice, ok := Choose(r, items)
+	assert.True(t, ok)
+	assert.Contains(t, items, choice)
+	_, ok = Choose(r, []int{})
+	assert.False(t, ok)
+}
+
+func TestRandomWeighted(t *testing.T) {
+	r := NewSeededRandom(5)
+	counts := make([]int, 3)
+	for i := 0; i < 10000; i++ {
+		counts[r.WeightedIndex([]float64{1, 0, 3})]++
+	}
+	assert.Equal(t, 0, counts[1])
+	assert.InDelta(t, 0.75, float64(counts[2])/10000, 0.03)
+
+	assert.Equal(t, -1, r.WeightedIndex([]float64{0, -1}))
+
+	type partner struct {
+		name   string
+		weight float64
+	}
+	choice, ok := WeightedChoice(r, []partner{{"a", 0}, {"b", 2}}, func(p partner) float64 { return p.weight })
+	assert.True(t, ok)
+	assert.Equal(t, "b", choice.name)
+	_, ok = WeightedChoice(r, nil, func(p partner) float64 { return p.weight })
+	assert.False(t, ok)
+}
+
+func TestRandomStrings(t *testing.T) {
+	code := CryptoRandom.String(12, AlphabetDigits)
+	assert.Len(t, code, 12)
+	assert.Equal(t, "", strings.Trim(code, AlphabetDigits))
+	assert.Len(t, CryptoRandom.String(8, ""), 8)
+	assert.Equal(t, "", CryptoRandom.String(0, AlphabetHex))
+
+	id := CryptoRandom.HexID(16)
+	assert.Len(t, id, 32)
+	assert.Equal(t, "", strings.Trim(id, AlphabetHex))
+	assert.NotEqual(t, id, CryptoRandom.HexID(16))
+}
+
+func TestRandomConcurrent(t *testing.T) {
+	r := NewSeededRandom(9)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.IntBetween(0, 100)
+			r.Sample(10, 3)
+			r.HexID(4)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,311 @@
+package lib
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces events. It is a Waiter, so it can pace FanOut
+type RateLimiter interface {
+	// Take consumes an event when allowed, otherwise it returns the delay
+	// until the next one is
+	Take() (allowed bool, retryAfter time.Duration)
+	// Allow consumes an event when allowed
+	Allow() bool
+	// Wait blocks until an event is allowed and consumes it, or returns the
+	// ctx error when ctx is done first
+	Wait(ctx context.Context) error
+}
+
+// RateLimitOptions configures a rate limiter allowing Limit events per
+// Interval. A zero or negative Limit allows no event, a zero or negative
+// Interval allows every event
+type RateLimitOptions struct {
+	Limit    int
+	Interval time.Duration
+	// Burst is the number of events allowed at once by a TokenBucket, Limit
+	// when zero
+	Burst int
+	// Clock measures time, SystemClock when nil
+	Clock Clock
+}
+
+func (opts RateLimitOptions) clock() Clock {
+	if opts.Clock == nil {
+		return SystemClock
+	}
+	return opts.Clock
+}
+
+// waitFor implements RateLimiter.Wait with Take
+func waitFor(ctx context.Context, clock Clock, take func() (bool, time.Duration)) error {
+	for {
+		allowed, retryAfter := take()
+		if allowed {
+			return nil
+		}
+		select {
+		case <-clock.After(retryAfter):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TokenBucket is a RateLimiter refilling Limit tokens per Interval, up to
+// Burst, each event consuming one
+type TokenBucket struct {
+	mutex  sync.Mutex
+	clock  Clock
+	rate   float64 // tokens per nanosecond
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket
+func NewTokenBucket(opts RateLimitOptions) *TokenBucket {
+	if opts.Limit <= 0 {
+		opts.Limit, opts.Burst = 0, 0
+	}
+	burst := opts.Burst
+	if burst <= 0 {
+		burst = opts.Limit
+	}
+	rate := math.Inf(1)
+	if opts.Interval > 0 {
+		rate = float64(opts.Limit) / float64(opts.Interval)
+	}
+	if opts.Limit == 0 {
+		rate = 0
+	}
+	clock := opts.clock()
+	return &TokenBucket{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Take consumes a token when available
+func (b *TokenBucket) Take() (bool, time.Duration) {
+	if math.IsInf(b.rate, 1) {
+		return true, 0
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+float64(elapsed)*b.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, math.MaxInt64
+	}
+	return false, time.Duration(math.Ceil((1 - b.tokens) / b.rate))
+}
+
+// Allow consumes a token when available
+func (b *TokenBucket) Allow() bool {
+	allowed, _ := b.Take()
+	return allowed
+}
+
+// Wait blocks until a token is available and consumes it
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return waitFor(ctx, b.clock, b.Take)
+}
+
+// SlidingWindow is a RateLimiter allowing Limit events in any Interval long
+// window. It keeps the time of the last Limit events
+type SlidingWindow struct {
+	mutex    sync.Mutex
+	clock    Clock
+	interval time.Duration
+	events   []time.Time // ring buffer of the last events
+	next     int
+	count    int
+}
+
+// NewSlidingWindow creates an empty SlidingWindow
+func NewSlidingWindow(opts RateLimitOptions) *SlidingWindow {
+	limit := opts.Limit
+	if limit < 0 {
+		limit = 0
+	}
+	return &SlidingWindow{
+		clock:    opts.clock(),
+		interval: opts.Interval,
+		events:   make([]time.Time, limit),
+	}
+}
+
+// Take records an event when fewer than Limit happened in the last Interval
+func (w *SlidingWindow) Take() (bool, time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.events) == 0 {
+		return false, math.MaxInt64
+	}
+	now := w.clock.Now()
+	if w.count == len(w.events) {
+		// next is the oldest event when the buffer is full
+		if expiry := w.events[w.next].Add(w.interval); expiry.After(now) {
+			return false, expiry.Sub(now)
+		}
+		w.count--
+	}
+	w.events[w.next] = now
+	w.next = (w.next + 1) % len(w.events)
+	w.count++
+	return true, 0
+}
+
+// Allow records an event when allowed
+func (w *SlidingWindow) Allow() bool {
+	allowed, _ := w.Take()
+	return allowed
+}
+
+// Wait blocks until an event is allowed and records it
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	return waitFor(ctx, w.clock, w.Take)
+}
+
+// KeyedLimiter keeps a RateLimiter per key, e.g. per partner or client IP,
+// evicting the ones unused for IdleTimeout
+type KeyedLimiter struct {
+	// New creates the limiter of a key
+	New func() RateLimiter
+	// IdleTimeout evicts limiters unused for this long. Zero never evicts
+	IdleTimeout time.Duration
+	// MaxKeys evicts the least recently used limiter when exceeded. Zero is
+	// unlimited
+	MaxKeys int
+	// Clock measures idleness, SystemClock when nil
+	Clock Clock
+
+	mutex     sync.Mutex
+	limiters  map[string]*keyedLimiter
+	lastSweep time.Time
+}
+
+type keyedLimiter struct {
+	limiter  RateLimiter
+	lastUsed time.Time
+}
+
+// NewKeyedLimiter creates a KeyedLimiter of limiters created by newLimiter
+func NewKeyedLimiter(newLimiter func() RateLimiter, idleTimeout time.Duration) *KeyedLimiter {
+	return &KeyedLimiter{New: newLimiter, IdleTimeout: idleTimeout}
+}
+
+// Get returns the limiter of key, creating it when missing
+func (k *KeyedLimiter) Get(key string) RateLimiter {
+	clock := k.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now()
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if k.limiters == nil {
+		k.limiters = make(map[string]*keyedLimiter)
+		k.lastSweep = now
+	}
+	if k.IdleTimeout > 0 && now.Sub(k.lastSweep) >= k.IdleTimeout {
+		k.evictIdle(now)
+	}
+
+	entry, ok := k.limiters[key]
+	if !ok {
+		if k.MaxKeys > 0 && len(k.limiters) >= k.MaxKeys {
+			k.evictLeastRecentlyUsed()
+		}
+		entry = &keyedLimiter{limiter: k.New()}
+		k.limiters[key] = entry
+	}
+	entry.lastUsed = now
+	return entry.limiter
+}
+
+// Take consumes an event of key when allowed
+func (k *KeyedLimiter) Take(key string) (bool, time.Duration) {
+	return k.Get(key).Take()
+}
+
+// Allow consumes an event of key when allowed
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait blocks until an event of key is allowed and consumes it
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Len returns the number of limiters kept
+func (k *KeyedLimiter) Len() int {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return len(k.limiters)
+}
+
+func (k *KeyedLimiter) evictIdle(now time.Time) {
+	for key, entry := range k.limiters {
+		if now.Sub(entry.lastUsed) >= k.IdleTimeout {
+			delete(k.limiters, key)
+		}
+	}
+	k.lastSweep = now
+}
+
+func (k *KeyedLimiter) evictLeastRecentlyUsed() {
+	var oldestKey string
+	var oldest time.Time
+	found := false
+	for key, entry := range k.limiters {
+		if !found || entry.lastUsed.Before(oldest) {
+			oldestKey, oldest, found = key, entry.lastUsed, true
+		}
+	}
+	delete(k.limiters, oldestKey)
+}
+
+// RateLimitMiddleware rejects the requests exceeding the limit of their key
+// with a 429 and a Retry-After header, rendered by WriteError:
+//
+//	limiter := NewKeyedLimiter(func() RateLimiter {
+//		return NewTokenBucket(RateLimitOptions{Limit: 10, Interval: time.Second})
+//	}, 10*time.Minute)
+//	handler = RateLimitMiddleware(limiter, func(r *http.Request) string {
+//		return r.Header.Get("X-Client-Id")
+//	})(handler)
+func RateLimitMiddleware(limiter *KeyedLimiter, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter := limiter.Take(key(r))
+			if !allowed {
+				seconds := int64(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+				WriteError(w, NewHTTPError(http.StatusTooManyRequests, ErrorCodeRateLimited, "rate limit exceeded"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package lib
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	bucket := NewTokenBucket(RateLimitOptions{Limit: 2, Interval: time.Second, Burst: 3, Clock: clock})
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow(), "burst %d", i)
+	}
+	allowed, retryAfter := bucket.Take()
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	clock.now = clock.now.Add(250 * time.Millisecond)
+	allowed, retryAfter = bucket.Take()
+	assert.False(t, allowed)
+	assert.Equal(t, 250*time.Millisecond, retryAfter)
+
+	clock.now = clock.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow(), "refilled up to burst %d", i)
+	}
+	assert.False(t, bucket.Allow())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	window := NewSlidingWindow(RateLimitOptions{Limit: 2, Interval: time.Second, Clock: clock})
+
+	assert.True(t, window.Allow())
+	clock.now = clock.now.Add(400 * time.Millisecond)
+	assert.True(t, window.Allow())
+
+	allowed, retryAfter := window.Take()
+	assert.False(t, allowed)
+	assert.Equal(t, 600*time.Millisecond, retryAfter)
+
+	clock.now = clock.now.Add(600 * time.Millisecond)
+	assert.True(t, window.Allow())
+	allowed, retryAfter = window.Take()
+	assert.False(t, allowed)
+	assert.Equal(t, 400*time.Millisecond, retryAfter)
+
+	assert.False(t, NewSlidingWindow(RateLimitOptions{Interval: time.Second}).Allow())
+}
+
+func TestRateLimiterInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RateLimitOptions
+		allowed bool
+	}{
+		{"no limit nor interval", RateLimitOptions{}, false},
+		{"negative limit", RateLimitOptions{Limit: -1, Interval: time.Second, Burst: 3}, false},
+		{"no interval", RateLimitOptions{Limit: 2}, true},
+		{"negative interval", RateLimitOptions{Limit: 2, Interval: -time.Second}, true},
+	}
+	limiters := map[string]func(opts RateLimitOptions) RateLimiter{
+		"token bucket":   func(opts RateLimitOptions) RateLimiter { return NewTokenBucket(opts) },
+		"sliding window": func(opts RateLimitOptions) RateLimiter { return NewSlidingWindow(opts) },
+	}
+	for name, newLimiter := range limiters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				limiter := newLimiter(tt.opts)
+				for i := 0; i < 10; i++ {
+					allowed, retryAfter := limiter.Take()
+					assert.Equal(t, tt.allowed, allowed)
+					if !tt.allowed {
+						assert.Equal(t, time.Duration(math.MaxInt64), retryAfter)
+					}
+				}
+				if tt.allowed {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+					defer cancel()
+					assert.NoError(t, limiter.Wait(ctx))
+				}
+			})
+		}
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiters := map[string]func(clock Clock) RateLimiter{
+		"token bucket": func(clock Clock) RateLimiter {
+			return NewTokenBucket(RateLimitOptions{Limit: 1, Interval: time.Second, Clock: clock})
+		},
+		"sliding window": func(clock Clock) RateLimiter {
+			return NewSlidingWindow(RateLimitOptions{Limit: 1, Interval: time.Second, Clock: clock})
+		},
+	}
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			limiter := newLimiter(clock)
+			for i := 0; i < 3; i++ {
+				assert.NoError(t, limiter.Wait(context.Background()))
+			}
+			assert.Equal(t, []time.Duration{time.Second, time.Second}, clock.sleeps)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			limiter = newLimiter(SystemClock)
+			limiter.Allow()
+			assert.Equal(t, context.Canceled, limiter.Wait(ctx))
+		})
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	keyed := NewKeyedLimiter(func() RateLimiter {
+		return NewTokenBucket(RateLimitOptions{Limit: 1, Interval: time.Minute, Clock: clock})
+	}, 10*time.Minute)
+	keyed.Clock = clock
+	keyed.MaxKeys = 2
+
+	assert.True(t, keyed.Allow("partner-a"))
+	assert.False(t, keyed.Allow("partner-a"))
+	assert.True(t, keyed.Allow("partner-b"))
+	assert.Equal(t, 2, keyed.Len())
+
+	clock.now = clock.now.Add(time.Second)
+	keyed.Allow("partner-b")
+	assert.True(t, keyed.Allow("partner-c"), "evicts partner-a")
+	assert.Equal(t, 2, keyed.Len())
+	assert.True(t, keyed.Allow("partner-a"), "new limiter")
+
+	clock.now = clock.now.Add(10 * time.Minute)
+	assert.True(t, keyed.Allow("partner-d"))
+	assert.Equal(t, 1, keyed.Len(), "idle limiters are evicted")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	keyed := NewKeyedLimiter(func() RateLimiter {
+		return NewSlidingWindow(RateLimitOptions{Limit: 1, Interval: 1500 * time.Millisecond, Clock: clock})
+	}, time.Minute)
+	handler := RateLimitMiddleware(keyed, func(r *http.Request) string {
+		return r.Header.Get("X-Client-Id")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/hotels", nil)
+		r.Header.Set("X-Client-Id", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("a").Code)
+	assert.Equal(t, http.StatusNoContent, request("b").Code)
+
+	w := request("a")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error": {"status": 429, "code": "rate_limited", "message": "rate limit exceeded"}}`, w.Body.String())
+
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, request("a").Code)
+}
+
+func TestRateLimiterPacesFanOut(t *testing.T) {
+	var limiter Waiter = NewTokenBucket(RateLimitOptions{Limit: 100, Interval: time.Second, Burst: 1})
+	start := time.Now()
+	_, err := FanOut(context.Background(), []int{1, 2, 3}, func(ctx context.Context, in int) (int, error) {
+		return in, nil
+	}, FanOutOptions{Limiter: limiter})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 15*time.Millisecond)
+}
@@ -0,0 +1,371 @@
+package lib
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// IsSlice reports whether arg, or the value it points to, is a slice
+func IsSlice(arg interface{}) bool {
+	return indirectKind(arg) == reflect.Slice
+}
+
+// IsMap reports whether arg, or the value it points to, is a map
+func IsMap(arg interface{}) bool {
+	return indirectKind(arg) == reflect.Map
+}
+
+// IsStruct reports whether arg, or the value it points to, is a struct
+func IsStruct(arg interface{}) bool {
+	return indirectKind(arg) == reflect.Struct
+}
+
+// IsNumeric reports whether arg, or the value it points to, is an integer,
+// unsigned integer or float
+func IsNumeric(arg interface{}) bool {
+	kind := indirectKind(arg)
+	return isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+// IsNil reports whether arg is nil or a nil pointer, map, slice, channel,
+// function or interface
+func IsNil(arg interface{}) bool {
+	if arg == nil {
+		return true
+	}
+	value := reflect.ValueOf(arg)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+// IsZero reports whether arg is nil or the zero value of its type. Empty
+// slices and maps are considered zero
+func IsZero(arg interface{}) bool {
+	return arg == nil || isZeroValue(reflect.ValueOf(arg))
+}
+
+// Indirect dereferences pointers until reaching a non pointer value. It
+// returns nil when a nil pointer is found
+func Indirect(arg interface{}) interface{} {
+	value := IndirectValue(reflect.ValueOf(arg))
+	if !value.IsValid() {
+		return nil
+	}
+	return value.Interface()
+}
+
+// IndirectValue dereferences pointers and interfaces. It returns the zero
+// reflect.Value when a nil one is found
+func IndirectValue(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// IndirectType returns the base type of t, e.g. Hotel for **Hotel
+func IndirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func indirectKind(arg interface{}) reflect.Kind {
+	if arg == nil {
+		return reflect.Invalid
+	}
+	return IndirectType(reflect.TypeOf(arg)).Kind()
+}
+
+func isZeroValue(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Map, reflect.Slice:
+		return value.IsNil() || value.Len() == 0
+	case reflect.Array, reflect.String:
+		return value.Len() == 0
+	}
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+// FieldInfo describes an exported struct field as seen through a tag
+type FieldInfo struct {
+	// Name of the Go field
+	Name string
+	// TagName is the name given by the tag, or Name when the tag is absent
+	TagName string
+	// Options are the tag options after the name, e.g. omitempty
+	Options []string
+	// Index is the field index sequence, for use with FieldByIndex
+	Index []int
+	Type  reflect.Type
+}
+
+// HasOption reports whether the tag of the field has the given option
+func (f FieldInfo) HasOption(option string) bool {
+	for _, o := range f.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+type structFieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var structFieldsCache sync.Map
+
+// StructFields lists the exported fields of a struct type as seen through
+// the given tag. Fields tagged "-" are skipped and the fields of untagged
+// embedded structs are promoted, as done by encoding/json. The result is
+// cached by type and tag, and must not be modified
+func StructFields(t reflect.Type, tag string) []FieldInfo {
+	t = IndirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	key := structFieldsKey{t: t, tag: tag}
+	if cached, ok := structFieldsCache.Load(key); ok {
+		return cached.([]FieldInfo)
+	}
+	fields := collectStructFields(t, tag, nil)
+	structFieldsCache.Store(key, fields)
+	return fields
+}
+
+func collectStructFields(t reflect.Type, tag string, index []int) (fields []FieldInfo) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		tagValue := ""
+		if tag != "" {
+			tagValue = field.Tag.Get(tag)
+		}
+		if tagValue == "-" {
+			continue
+		}
+		parts := strings.Split(tagValue, ",")
+		name := parts[0]
+
+		if field.Anonymous && name == "" {
+			embedded := IndirectType(field.Type)
+			if embedded.Kind() == reflect.Struct && field.Type.Kind() != reflect.Ptr {
+				fields = append(fields, collectStructFields(embedded, tag, fieldIndex)...)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, FieldInfo{
+			Name:    field.Name,
+			TagName: name,
+			Options: parts[1:],
+			Index:   fieldIndex,
+			Type:    field.Type,
+		})
+	}
+	return fields
+}
+
+// ForEachField calls fn for each field of the struct held or pointed by
+// obj, as listed by StructFields. It stops at the first error returned by fn
+func ForEachField(obj interface{}, tag string, fn func(field FieldInfo, value reflect.Value) error) error {
+	value := IndirectValue(reflect.ValueOf(obj))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return errors.Errorf("ForEachField: expected struct, got %T", obj)
+	}
+	for _, field := range StructFields(value.Type(), tag) {
+		if err := fn(field, value.FieldByIndex(field.Index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetField returns the value found following a dotted path from obj.
+// Segments match struct field names or json tag names, slice and array
+// indexes and map keys:
+//
+//	GetField(booking, "Rooms.0.Guests.0.Name")
+func GetField(obj interface{}, path string) (interface{}, error) {
+	value, err := walkPath(reflect.ValueOf(obj), path, false)
+	if err != nil {
+		return nil, err
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+	return value.Interface(), nil
+}
+
+// SetField sets the value found following a dotted path from obj, which
+// must be a pointer. Nil pointers found along the path are allocated and
+// the value is converted to the field type when possible
+func SetField(obj interface{}, path string, value interface{}) error {
+	root := reflect.ValueOf(obj)
+	if root.Kind() != reflect.Ptr || root.IsNil() {
+		return errors.Errorf("SetField: expected non-nil pointer, got %T", obj)
+	}
+
+	segments := strings.Split(path, ".")
+	parentPath := strings.Join(segments[:len(segments)-1], ".")
+	last := segments[len(segments)-1]
+
+	parent := root
+	if parentPath != "" {
+		var err error
+		if parent, err = walkPath(root, parentPath, true); err != nil {
+			return err
+		}
+	}
+	parent = allocIndirect(parent)
+
+	if parent.Kind() == reflect.Map {
+		if parent.IsNil() {
+			parent.Set(reflect.MakeMap(parent.Type()))
+		}
+		key, err := convertValue(reflect.ValueOf(last), parent.Type().Key())
+		if err != nil {
+			return errors.Wrapf(err, "SetField: key %s", last)
+		}
+		converted, err := convertValue(reflect.ValueOf(value), parent.Type().Elem())
+		if err != nil {
+			return errors.Wrapf(err, "SetField: %s", path)
+		}
+		parent.SetMapIndex(key, converted)
+		return nil
+	}
+
+	field, err := pathSegment(parent, last)
+	if err != nil {
+		return errors.Wrapf(err, "SetField: %s", path)
+	}
+	if !field.CanSet() {
+		return errors.Errorf("SetField: %s cannot be set", path)
+	}
+	converted, err := convertValue(reflect.ValueOf(value), field.Type())
+	if err != nil {
+		return errors.Wrapf(err, "SetField: %s", path)
+	}
+	field.Set(converted)
+	return nil
+}
+
+func walkPath(value reflect.Value, path string, alloc bool) (reflect.Value, error) {
+	for _, segment := range strings.Split(path, ".") {
+		if alloc {
+			value = allocIndirect(value)
+		} else {
+			value = IndirectValue(value)
+		}
+		if !value.IsValid() {
+			return reflect.Value{}, nil
+		}
+		next, err := pathSegment(value, segment)
+		if err != nil {
+			return reflect.Value{}, errors.Wrapf(err, "path %s", path)
+		}
+		value = next
+	}
+	return value, nil
+}
+
+// allocIndirect dereferences pointers allocating the nil ones when settable
+func allocIndirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			if value.Kind() == reflect.Interface || !value.CanSet() {
+				return reflect.Value{}
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func pathSegment(value reflect.Value, segment string) (reflect.Value, error) {
+	switch value.Kind() {
+	case reflect.Struct:
+		if field := value.FieldByName(segment); field.IsValid() && isExportedName(segment) {
+			return field, nil
+		}
+		for _, field := range StructFields(value.Type(), "json") {
+			if field.TagName == segment {
+				return value.FieldByIndex(field.Index), nil
+			}
+		}
+		return reflect.Value{}, errors.Errorf("field %s not found in %s", segment, value.Type())
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= value.Len() {
+			return reflect.Value{}, errors.Errorf("index %s out of range", segment)
+		}
+		return value.Index(i), nil
+	case reflect.Map:
+		key, err := convertValue(reflect.ValueOf(segment), value.Type().Key())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return value.MapIndex(key), nil
+	}
+	return reflect.Value{}, errors.Errorf("cannot walk into %s with %s", value.Type(), segment)
+}
+
+func isExportedName(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// convertValue converts value to type t when it is assignable or
+// convertible. Strings are parsed as done by Binder
+func convertValue(value reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if !value.IsValid() {
+		return reflect.Zero(t), nil
+	}
+	if value.Type().AssignableTo(t) {
+		return value, nil
+	}
+	if value.Kind() == reflect.String && t.Kind() != reflect.String {
+		target := reflect.New(t).Elem()
+		if err := setFieldFromStrings(target, []string{value.String()}); err != nil {
+			return reflect.Value{}, err
+		}
+		return target, nil
+	}
+	if value.Type().ConvertibleTo(t) && !(t.Kind() == reflect.String && value.Kind() != reflect.String) {
+		return value.Convert(t), nil
+	}
+	if t.Kind() == reflect.Ptr {
+		converted, err := convertValue(value, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(converted)
+		return ptr, nil
+	}
+	return reflect.Value{}, errors.Errorf("cannot convert %s to %s", value.Type(), t)
+}
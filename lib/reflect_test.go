@@ -0,0 +1,217 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type reflectGuest struct {
+	Name string `json:"name"`
+	Age  int    `json:"age,omitempty"`
+}
+
+type reflectAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type reflectBooking struct {
+	reflectAudit
+	ID       int64             `json:"id"`
+	Hotel    *string           `json:"hotel"`
+	Guests   []reflectGuest    `json:"guests"`
+	Main     *reflectGuest     `json:"main_guest"`
+	Extras   map[string]string `json:"extras"`
+	Rooms    [2]int            `json:"rooms"`
+	Internal string            `json:"-"`
+	secret   string
+}
+
+func TestKindPredicates(t *testing.T) {
+	slice := []int{1}
+	array := [2]int{1, 2}
+	m := map[string]int{}
+	number := 10.5
+	var nilSlice *[]int
+
+	tests := []struct {
+		name    string
+		arg     interface{}
+		slice   bool
+		array   bool
+		isMap   bool
+		strct   bool
+		numeric bool
+	}{
+		{"slice", slice, true, true, false, false, false},
+		{"slice pointer", &slice, true, true, false, false, false},
+		{"nil slice pointer", nilSlice, true, true, false, false, false},
+		{"array", array, false, true, false, false, false},
+		{"array pointer", &array, false, true, false, false, false},
+		{"map", m, false, false, true, false, false},
+		{"struct", reflectGuest{}, false, false, false, true, false},
+		{"struct pointer", &reflectGuest{}, false, false, false, true, false},
+		{"float pointer", &number, false, false, false, false, true},
+		{"uint8", uint8(1), false, false, false, false, true},
+		{"string", "10", false, false, false, false, false},
+		{"nil", nil, false, false, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.slice, IsSlice(tt.arg))
+			assert.Equal(t, tt.array, IsArray(tt.arg))
+			assert.Equal(t, tt.isMap, IsMap(tt.arg))
+			assert.Equal(t, tt.strct, IsStruct(tt.arg))
+			assert.Equal(t, tt.numeric, IsNumeric(tt.arg))
+		})
+	}
+
+	s := "foo"
+	assert.True(t, IsString(&s))
+}
+
+func TestIsNilAndIsZero(t *testing.T) {
+	var nilGuest *reflectGuest
+	var nilMap map[string]int
+	var nilErr error
+
+	assert.True(t, IsNil(nil))
+	assert.True(t, IsNil(nilGuest))
+	assert.True(t, IsNil(nilMap))
+	assert.True(t, IsNil(nilErr))
+	assert.False(t, IsNil(0))
+	assert.False(t, IsNil(&reflectGuest{}))
+
+	assert.True(t, IsZero(nil))
+	assert.True(t, IsZero(0))
+	assert.True(t, IsZero(""))
+	assert.True(t, IsZero([]int{}))
+	assert.True(t, IsZero(reflectGuest{}))
+	assert.True(t, IsZero(nilGuest))
+	assert.False(t, IsZero(&reflectGuest{}))
+	assert.False(t, IsZero(reflectGuest{Age: 1}))
+	assert.True(t, IsZero(false))
+}
+
+func TestIndirect(t *testing.T) {
+	guest := &reflectGuest{Name: "Ana"}
+	guestPtr := &guest
+	var nilGuest *reflectGuest
+
+	assert.Equal(t, reflectGuest{Name: "Ana"}, Indirect(guestPtr))
+	assert.Equal(t, 10, Indirect(10))
+	assert.Nil(t, Indirect(nilGuest))
+	assert.Equal(t, reflect.TypeOf(reflectGuest{}), IndirectType(reflect.TypeOf(guestPtr)))
+	assert.Nil(t, IndirectType(nil))
+}
+
+func TestStructFields(t *testing.T) {
+	fields := StructFields(reflect.TypeOf(&reflectBooking{}), "json")
+
+	names := []string{}
+	for _, field := range fields {
+		names = append(names, field.TagName)
+	}
+	assert.Equal(t, []string{"created_by", "id", "hotel", "guests", "main_guest", "extras", "rooms"}, names)
+	assert.Equal(t, "CreatedBy", fields[0].Name)
+	assert.Equal(t, []int{0, 0}, fields[0].Index)
+
+	guestFields := StructFields(reflect.TypeOf(reflectGuest{}), "json")
+	assert.True(t, guestFields[1].HasOption("omitempty"))
+	assert.False(t, guestFields[0].HasOption("omitempty"))
+
+	untagged := StructFields(reflect.TypeOf(reflectGuest{}), "")
+	assert.Equal(t, "Name", untagged[0].TagName)
+
+	assert.Nil(t, StructFields(reflect.TypeOf(10), "json"))
+}
+
+func TestForEachField(t *testing.T) {
+	guest := reflectGuest{Name: "Ana", Age: 30}
+	values := map[string]interface{}{}
+	err := ForEachField(&guest, "json", func(field FieldInfo, value reflect.Value) error {
+		values[field.TagName] = value.Interface()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "Ana", "age": 30}, values)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ForEachField(guest, "json", func(FieldInfo, reflect.Value) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	assert.Error(t, ForEachField(10, "json", nil))
+}
+
+func TestGetField(t *testing.T) {
+	hotel := "Copacabana Palace"
+	booking := &reflectBooking{
+		ID:     42,
+		Hotel:  &hotel,
+		Guests: []reflectGuest{{Name: "Ana"}, {Name: "Bruno", Age: 8}},
+		Extras: map[string]string{"breakfast": "yes"},
+		Rooms:  [2]int{101, 102},
+	}
+	booking.CreatedBy = "admin"
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"ID", int64(42)},
+		{"id", int64(42)},
+		{"Hotel", &hotel},
+		{"Guests.1.Name", "Bruno"},
+		{"guests.1.age", 8},
+		{"extras.breakfast", "yes"},
+		{"rooms.1", 102},
+		{"created_by", "admin"},
+		{"main_guest.name", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := GetField(booking, tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, path := range []string{"Unknown", "Guests.5.Name", "Guests.x", "secret", "ID.foo"} {
+		_, err := GetField(booking, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestSetField(t *testing.T) {
+	booking := &reflectBooking{Guests: []reflectGuest{{}}}
+
+	assert.NoError(t, SetField(booking, "id", "42"))
+	assert.NoError(t, SetField(booking, "Hotel", "Fasano"))
+	assert.NoError(t, SetField(booking, "guests.0.name", "Ana"))
+	assert.NoError(t, SetField(booking, "Guests.0.Age", 30.0))
+	assert.NoError(t, SetField(booking, "main_guest.name", "Bruno"))
+	assert.NoError(t, SetField(booking, "extras.breakfast", "yes"))
+	assert.NoError(t, SetField(booking, "Rooms.0", 101))
+	assert.NoError(t, SetField(booking, "CreatedBy", "admin"))
+
+	assert.Equal(t, int64(42), booking.ID)
+	assert.Equal(t, "Fasano", *booking.Hotel)
+	assert.Equal(t, reflectGuest{Name: "Ana", Age: 30}, booking.Guests[0])
+	assert.Equal(t, "Bruno", booking.Main.Name)
+	assert.Equal(t, map[string]string{"breakfast": "yes"}, booking.Extras)
+	assert.Equal(t, 101, booking.Rooms[0])
+	assert.Equal(t, "admin", booking.CreatedBy)
+
+	assert.Error(t, SetField(*booking, "ID", 1))
+	assert.Error(t, SetField(booking, "ID", "abc"))
+	assert.Error(t, SetField(booking, "ID", []int{1}))
+	assert.Error(t, SetField(booking, "Guests.3.Name", "Carla"))
+	assert.Error(t, SetField(booking, "secret", "x"))
+}
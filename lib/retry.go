@@ -0,0 +1,173 @@
+package lib
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits, replaced by a fake one in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the time package
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Backoff returns the delay before retrying the given failed attempt,
+// starting at 1, given the previous delay
+type Backoff func(attempt int, previous time.Duration) time.Duration
+
+// ConstantBackoff waits delay between attempts
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return delay
+	}
+}
+
+// LinearBackoff waits base times the attempt, up to max when positive
+func LinearBackoff(base, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		return capDelay(base*time.Duration(attempt), max)
+	}
+}
+
+// ExponentialBackoff waits base doubled on each attempt, up to max when
+// positive
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		delay := base
+		for i := 1; i < attempt && i < 32 && (max <= 0 || delay < max); i++ {
+			delay *= 2
+		}
+		return capDelay(delay, max)
+	}
+}
+
+// DecorrelatedJitterBackoff waits a random delay between base and three
+// times the previous one, up to max when positive
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return func(_ int, previous time.Duration) time.Duration {
+		if previous < base {
+			previous = base
+		}
+		return capDelay(base+jitter(previous*3-base), max)
+	}
+}
+
+// FullJitter waits a random delay between zero and the one of backoff
+func FullJitter(backoff Backoff) Backoff {
+	return func(attempt int, previous time.Duration) time.Duration {
+		return jitter(backoff(attempt, previous))
+	}
+}
+
+func capDelay(delay, max time.Duration) time.Duration {
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+type retryConfig struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	backoff     Backoff
+	retryIf     func(err error) bool
+	onRetry     func(attempt int, err error, delay time.Duration)
+	clock       Clock
+}
+
+// RetryOption configures Retry
+type RetryOption func(c *retryConfig)
+
+// RetryMaxAttempts sets the total number of attempts, including the first
+// one, 3 by default. Zero or negative is unlimited
+func RetryMaxAttempts(n int) RetryOption {
+	return func(c *retryConfig) { c.maxAttempts = n }
+}
+
+// RetryMaxElapsed stops retrying when the next attempt would start after d
+// since the first one
+func RetryMaxElapsed(d time.Duration) RetryOption {
+	return func(c *retryConfig) { c.maxElapsed = d }
+}
+
+// RetryBackoff sets the delays between attempts, full jitter exponential
+// from 100ms to 5s by default
+func RetryBackoff(backoff Backoff) RetryOption {
+	return func(c *retryConfig) { c.backoff = backoff }
+}
+
+// RetryIf sets the classifier of retryable errors. All errors are retried
+// by default
+func RetryIf(retryable func(err error) bool) RetryOption {
+	return func(c *retryConfig) { c.retryIf = retryable }
+}
+
+// RetryNotify sets a hook called before waiting to retry a failed attempt
+func RetryNotify(onRetry func(attempt int, err error, delay time.Duration)) RetryOption {
+	return func(c *retryConfig) { c.onRetry = onRetry }
+}
+
+// RetryClock sets the Clock measuring and waiting the delays, SystemClock
+// by default
+func RetryClock(clock Clock) RetryOption {
+	return func(c *retryConfig) { c.clock = clock }
+}
+
+// Retry calls fn until it succeeds, returns a non retryable error or the
+// attempts or elapsed time are exhausted, returning its last error. It
+// returns the ctx error when ctx is done while waiting:
+//
+//	err := Retry(ctx, func(ctx context.Context) error {
+//		return client.RefreshRates(ctx)
+//	}, RetryMaxAttempts(5), RetryBackoff(DecorrelatedJitterBackoff(50*time.Millisecond, 2*time.Second)))
+func Retry(ctx context.Context, fn func(ctx context.Context) error, opts ...RetryOption) error {
+	c := retryConfig{
+		maxAttempts: 3,
+		backoff:     FullJitter(ExponentialBackoff(100*time.Millisecond, 5*time.Second)),
+		clock:       SystemClock,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	start := c.clock.Now()
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if c.retryIf != nil && !c.retryIf(err) {
+			return err
+		}
+		if c.maxAttempts > 0 && attempt >= c.maxAttempts {
+			return err
+		}
+
+		delay = c.backoff(attempt, delay)
+		if c.maxElapsed > 0 && c.clock.Now().Add(delay).Sub(start) > c.maxElapsed {
+			return err
+		}
+		if c.onRetry != nil {
+			c.onRetry(attempt, err, delay)
+		}
+		select {
+		case <-c.clock.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{"constant", ConstantBackoff(time.Second), []time.Duration{time.Second, time.Second, time.Second}},
+		{"linear", LinearBackoff(time.Second, 5*time.Second), []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"exponential", ExponentialBackoff(time.Second, 10*time.Second), []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}},
+		{"exponential unlimited", ExponentialBackoff(time.Millisecond, 0), []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				assert.Equal(t, want, tt.backoff(i+1, 0), "attempt %d", i+1)
+			}
+		})
+	}
+	assert.Equal(t, time.Duration(1<<31), ExponentialBackoff(time.Nanosecond, 0)(100, 0))
+}
+
+func TestJitterBackoff(t *testing.T) {
+	decorrelated := DecorrelatedJitterBackoff(10*time.Millisecond, time.Second)
+	var delay time.Duration
+	for attempt := 1; attempt < 20; attempt++ {
+		previous := delay
+		delay = decorrelated(attempt, previous)
+		assert.True(t, delay >= 10*time.Millisecond && delay <= time.Second, "%s", delay)
+		assert.True(t, delay <= 30*time.Millisecond || delay <= previous*3, "%s after %s", delay, previous)
+	}
+
+	full := FullJitter(ConstantBackoff(time.Second))
+	for attempt := 1; attempt < 20; attempt++ {
+		delay := full(attempt, 0)
+		assert.True(t, delay >= 0 && delay < time.Second, "%s", delay)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	var notified []int
+	attempts := 0
+	err := Retry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	},
+		RetryClock(clock),
+		RetryBackoff(LinearBackoff(time.Second, 0)),
+		RetryNotify(func(attempt int, err error, delay time.Duration) {
+			assert.Equal(t, io.ErrUnexpectedEOF, err)
+			notified = append(notified, attempt)
+		}),
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, notified)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.sleeps)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	failure := errors.New("partner unavailable")
+	permanent := errors.New("invalid hotel id")
+	tests := []struct {
+		name     string
+		errs     []error
+		opts     []RetryOption
+		attempts int
+		err      error
+	}{
+		{"max attempts", []error{failure}, []RetryOption{RetryMaxAttempts(4)}, 4, failure},
+		{"default max attempts", []error{failure}, nil, 3, failure},
+		{"max elapsed", []error{failure}, []RetryOption{RetryMaxAttempts(0), RetryMaxElapsed(10 * time.Second)}, 4, failure},
+		{
+			name:     "not retryable",
+			errs:     []error{failure, permanent},
+			opts:     []RetryOption{RetryIf(func(err error) bool { return err != permanent })},
+			attempts: 2,
+			err:      permanent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			attempts := 0
+			opts := append([]RetryOption{RetryClock(clock), RetryBackoff(ConstantBackoff(3 * time.Second))}, tt.opts...)
+			err := Retry(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[len(tt.errs)-1]
+				if attempts < len(tt.errs) {
+					err = tt.errs[attempts]
+				}
+				attempts++
+				return err
+			}, opts...)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := Retry(ctx, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return ctx.Err()
+	}, RetryBackoff(ConstantBackoff(time.Hour)))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, attempts)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Retry(ctx, func(ctx context.Context) error { return io.EOF }, RetryBackoff(ConstantBackoff(time.Hour)))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MapOptions configures StructToMapWithOptions and MapToStructWithOptions
+type MapOptions struct {
+	// Tag naming the map keys, e.g. json, url or a custom one. Fields without
+	// the tag use their Go name and fields tagged "-" are skipped
+	Tag string
+	// OmitEmpty skips every zero field. Fields tagged omitempty are always
+	// skipped when zero
+	OmitEmpty bool
+	// Flatten writes nested structs as dotted keys, e.g. address.city,
+	// instead of nested maps
+	Flatten bool
+	// Separator joins flattened keys, "." when empty
+	Separator string
+}
+
+// StructToMap converts a struct, or a pointer to one, into a map keyed by
+// json tag names
+func StructToMap(obj interface{}) (map[string]interface{}, error) {
+	return StructToMapWithOptions(obj, MapOptions{Tag: "json"})
+}
+
+// StructToMapWithOptions converts a struct, or a pointer to one, into a map.
+// Fields of untagged embedded structs are promoted and nested structs become
+// nested maps or, with Flatten, dotted keys:
+//
+//	StructToMapWithOptions(search, MapOptions{Tag: "url", Flatten: true})
+//	// map[checkin:2018-10-05 room.adults:2 room.children:1]
+//
+// Times and other values are kept as they are
+func StructToMapWithOptions(obj interface{}, opts MapOptions) (map[string]interface{}, error) {
+	value := IndirectValue(reflect.ValueOf(obj))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return nil, errors.Errorf("StructToMap: expected struct, got %T", obj)
+	}
+	m := map[string]interface{}{}
+	structToMap(value, opts.withDefaults(), "", m)
+	return m, nil
+}
+
+func (opts MapOptions) withDefaults() MapOptions {
+	if opts.Separator == "" {
+		opts.Separator = "."
+	}
+	return opts
+}
+
+func structToMap(value reflect.Value, opts MapOptions, prefix string, m map[string]interface{}) {
+	for _, field := range StructFields(value.Type(), opts.Tag) {
+		fieldValue := value.FieldByIndex(field.Index)
+		if (opts.OmitEmpty || field.HasOption("omitempty")) && isZeroValue(fieldValue) {
+			continue
+		}
+		key := prefix + field.TagName
+
+		if isNestedStruct(field.Type, opts.Tag) {
+			nested := IndirectValue(fieldValue)
+			switch {
+			case !nested.IsValid():
+				m[key] = nil
+			case opts.Flatten:
+				structToMap(nested, opts, key+opts.Separator, m)
+			default:
+				nestedMap := map[string]interface{}{}
+				structToMap(nested, opts, "", nestedMap)
+				m[key] = nestedMap
+			}
+			continue
+		}
+		m[key] = valueInterface(fieldValue)
+	}
+}
+
+// MapToStruct fills the struct pointed by dst with the values of m keyed by
+// json tag names. See MapToStructWithOptions
+func MapToStruct(m map[string]interface{}, dst interface{}) error {
+	return MapToStructWithOptions(m, dst, MapOptions{Tag: "json"})
+}
+
+// MapToStructWithOptions fills the struct pointed by dst with the values of
+// m, reversing StructToMapWithOptions. Values are converted as done by Copy,
+// so strings are parsed into numbers, booleans and times. Nested structs are
+// read from nested maps or, with Flatten, from dotted keys. Keys without a
+// matching field are ignored
+func MapToStructWithOptions(m map[string]interface{}, dst interface{}, opts MapOptions) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("MapToStruct: expected pointer to struct, got %T", dst)
+	}
+	if err := mapToStruct(m, value.Elem(), opts.withDefaults(), ""); err != nil {
+		return errors.Wrap(err, "MapToStruct")
+	}
+	return nil
+}
+
+func mapToStruct(m map[string]interface{}, value reflect.Value, opts MapOptions, prefix string) error {
+	c := copier{opts: CopyOptions{Tag: opts.Tag}}
+	for _, field := range StructFields(value.Type(), opts.Tag) {
+		key := prefix + field.TagName
+		fieldValue := value.FieldByIndex(field.Index)
+		item, ok := m[key]
+
+		if isNestedStruct(field.Type, opts.Tag) {
+			if nested, isMap := item.(map[string]interface{}); isMap {
+				if err := mapToStruct(nested, allocIndirect(fieldValue), opts, ""); err != nil {
+					return errors.Wrap(err, key)
+				}
+				continue
+			}
+			if !ok && opts.Flatten && hasKeyPrefix(m, key+opts.Separator) {
+				if err := mapToStruct(m, allocIndirect(fieldValue), opts, key+opts.Separator); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := c.copy(fieldValue, reflect.ValueOf(item), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNestedStruct reports whether t is a struct, or a pointer to one, with
+// fields to be written as a nested map. Times are kept as values
+func isNestedStruct(t reflect.Type, tag string) bool {
+	t = IndirectType(t)
+	return t != nil && t.Kind() == reflect.Struct && t != timeType && len(StructFields(t, tag)) > 0
+}
+
+func hasKeyPrefix(m map[string]interface{}, prefix string) bool {
+	for key := range m {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapRoom struct {
+	Adults   int `json:"adults" url:"adults"`
+	Children int `json:"children,omitempty" url:"children,omitempty"`
+}
+
+type mapPaging struct {
+	Page int `json:"page" url:"page"`
+}
+
+type mapSearch struct {
+	mapPaging
+	City     string    `json:"city" url:"city"`
+	CheckIn  time.Time `json:"check_in" url:"checkin"`
+	Room     mapRoom   `json:"room" url:"room"`
+	Extra    *mapRoom  `json:"extra" url:"extra,omitempty"`
+	Tags     []string  `json:"tags,omitempty" url:"tags"`
+	Internal string    `json:"-" url:"-"`
+	Currency string
+}
+
+func TestStructToMap(t *testing.T) {
+	checkIn := time.Date(2018, 10, 5, 0, 0, 0, 0, time.UTC)
+	search := mapSearch{
+		mapPaging: mapPaging{Page: 2},
+		City:      "Rio de Janeiro",
+		CheckIn:   checkIn,
+		Room:      mapRoom{Adults: 2},
+		Internal:  "ignored",
+		Currency:  "BRL",
+	}
+
+	m, err := StructToMap(&search)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"page":     2,
+		"city":     "Rio de Janeiro",
+		"check_in": checkIn,
+		"room":     map[string]interface{}{"adults": 2},
+		"extra":    nil,
+		"Currency": "BRL",
+	}, m)
+
+	search.Room.Children = 1
+	search.Tags = []string{"pool"}
+	m, err = StructToMapWithOptions(search, MapOptions{Tag: "url", Flatten: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"page":          2,
+		"city":          "Rio de Janeiro",
+		"checkin":       checkIn,
+		"room.adults":   2,
+		"room.children": 1,
+		"tags":          []string{"pool"},
+		"Currency":      "BRL",
+	}, m)
+
+	m, err = StructToMapWithOptions(mapSearch{City: "Búzios"}, MapOptions{Tag: "url", OmitEmpty: true, Separator: "_", Flatten: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"city": "Búzios"}, m)
+
+	_, err = StructToMap("foo")
+	assert.Error(t, err)
+}
+
+func TestMapToStruct(t *testing.T) {
+	var search mapSearch
+	err := MapToStruct(map[string]interface{}{
+		"page":     "3",
+		"city":     "Paraty",
+		"check_in": "2018-10-05",
+		"room":     map[string]interface{}{"adults": 2.0, "children": "1"},
+		"extra":    map[string]interface{}{"adults": 1},
+		"tags":     []interface{}{"pool", "spa"},
+		"Currency": "USD",
+		"unknown":  true,
+	}, &search)
+	assert.NoError(t, err)
+	assert.Equal(t, mapSearch{
+		mapPaging: mapPaging{Page: 3},
+		City:      "Paraty",
+		CheckIn:   time.Date(2018, 10, 5, 0, 0, 0, 0, time.UTC),
+		Room:      mapRoom{Adults: 2, Children: 1},
+		Extra:     &mapRoom{Adults: 1},
+		Tags:      []string{"pool", "spa"},
+		Currency:  "USD",
+	}, search)
+
+	var flat mapSearch
+	err = MapToStructWithOptions(map[string]interface{}{
+		"room_adults":  "2",
+		"extra_adults": 3,
+	}, &flat, MapOptions{Tag: "url", Flatten: true, Separator: "_"})
+	assert.NoError(t, err)
+	assert.Equal(t, mapRoom{Adults: 2}, flat.Room)
+	assert.Equal(t, &mapRoom{Adults: 3}, flat.Extra)
+
+	err = MapToStruct(map[string]interface{}{"room": map[string]interface{}{"adults": "two"}}, &search)
+	if assert.Error(t, err) {
+		assert.Equal(t, "MapToStruct: room: adults: must be an integer", err.Error())
+	}
+	assert.Error(t, MapToStruct(nil, search))
+}
+
+func TestStructMapRoundTrip(t *testing.T) {
+	search := mapSearch{
+		mapPaging: mapPaging{Page: 1},
+		City:      "Gramado",
+		Room:      mapRoom{Adults: 2, Children: 2},
+		Extra:     &mapRoom{Adults: 1},
+	}
+	opts := MapOptions{Tag: "json", Flatten: true}
+	m, err := StructToMapWithOptions(search, opts)
+	assert.NoError(t, err)
+
+	var back mapSearch
+	assert.NoError(t, MapToStructWithOptions(m, &back, opts))
+	assert.Equal(t, search, back)
+}
@@ -0,0 +1,220 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// LetterTransliterations maps letters which have no accent decomposition to
+// their ASCII spelling
+var LetterTransliterations = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'Æ': "AE",
+	'ø': "o",
+	'Ø': "O",
+	'œ': "oe",
+	'Œ': "OE",
+	'đ': "d",
+	'Đ': "D",
+	'ð': "d",
+	'Ð': "D",
+	'ł': "l",
+	'Ł': "L",
+	'þ': "th",
+	'Þ': "TH",
+	'ı': "i",
+	'ª': "a",
+	'º': "o",
+}
+
+// Transliteration tables by language, spelling symbols as words
+var (
+	PortugueseTransliterations = mergeTransliterations(LetterTransliterations, map[rune]string{'&': "e"})
+	SpanishTransliterations    = mergeTransliterations(LetterTransliterations, map[rune]string{'&': "y"})
+	EnglishTransliterations    = mergeTransliterations(LetterTransliterations, map[rune]string{'&': "and"})
+)
+
+// DefaultTransliterations is the table used by Slugify
+var DefaultTransliterations = PortugueseTransliterations
+
+func mergeTransliterations(tables ...map[rune]string) map[rune]string {
+	merged := map[rune]string{}
+	for _, table := range tables {
+		for r, replacement := range table {
+			merged[r] = replacement
+		}
+	}
+	return merged
+}
+
+// SlugOptions configures Slugify
+type SlugOptions struct {
+	// Separator between words. Defaults to "-"
+	Separator string
+	// Transliterations replaces runes before building the slug. Defaults to
+	// DefaultTransliterations
+	Transliterations map[rune]string
+	// MaxLength cuts the slug at a separator so it is at most MaxLength bytes
+	// long. Zero means no limit
+	MaxLength int
+}
+
+// RemoveAccents strips diacritics using Unicode NFD decomposition:
+// "Florianópolis" becomes "Florianopolis" and "Peñíscola" becomes "Peniscola"
+func RemoveAccents(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// CollapseWhitespace trims s and replaces every whitespace run, line breaks
+// included, by a single space
+func CollapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// NormalizeSearchKey returns a lower case, accent free and whitespace
+// collapsed version of s, suitable for matching user input
+func NormalizeSearchKey(s string) string {
+	return strings.ToLower(CollapseWhitespace(RemoveAccents(s)))
+}
+
+// Slugify converts s into a URL slug using the default options:
+// "Hotel Fasano & Spa - São Paulo" becomes "hotel-fasano-e-spa-sao-paulo"
+func Slugify(s string) string {
+	return SlugifyWithOptions(s, SlugOptions{})
+}
+
+// SlugifyWithOptions converts s into a URL slug made of lower case ASCII
+// letters and digits joined by the separator
+func SlugifyWithOptions(s string, opts SlugOptions) string {
+	separator := opts.Separator
+	if separator == "" {
+		separator = "-"
+	}
+	transliterations := opts.Transliterations
+	if transliterations == nil {
+		transliterations = DefaultTransliterations
+	}
+
+	var transliterated strings.Builder
+	for _, r := range RemoveAccents(s) {
+		if replacement, ok := transliterations[r]; ok {
+			if !unicode.IsLetter(r) {
+				replacement = " " + replacement + " "
+			}
+			transliterated.WriteString(replacement)
+			continue
+		}
+		transliterated.WriteRune(r)
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(transliterated.String()), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	slug := strings.Join(words, separator)
+	if opts.MaxLength > 0 && len(slug) > opts.MaxLength {
+		cutsWord := !strings.HasPrefix(slug[opts.MaxLength:], separator)
+		slug = slug[:opts.MaxLength]
+		if i := strings.LastIndex(slug, separator); cutsWord && i > 0 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimSuffix(slug, separator)
+	}
+	return slug
+}
+
+// SplitWords breaks s into words on whitespace, punctuation, case changes
+// and letter to digit transitions: "HTTPServerPort8080" gives
+// ["HTTP", "Server", "Port", "8080"]
+func SplitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+
+	rs := []rune(s)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 {
+			prev := current[len(current)-1]
+			switch {
+			case unicode.IsDigit(r) != unicode.IsDigit(prev) && !unicode.Is(unicode.Mn, r):
+				flush()
+			case unicode.IsUpper(r) && unicode.IsLower(prev):
+				flush()
+			case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]):
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// ToSnakeCase converts s to snake_case
+func ToSnakeCase(s string) string {
+	return joinLowerWords(s, "_")
+}
+
+// ToKebabCase converts s to kebab-case
+func ToKebabCase(s string) string {
+	return joinLowerWords(s, "-")
+}
+
+// ToCamelCase converts s to camelCase
+func ToCamelCase(s string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+			continue
+		}
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+// ToPascalCase converts s to PascalCase
+func ToPascalCase(s string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+func joinLowerWords(s, separator string) string {
+	words := SplitWords(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, separator)
+}
+
+func capitalize(word string) string {
+	rs := []rune(strings.ToLower(word))
+	if len(rs) == 0 {
+		return ""
+	}
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveAccents(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Florianópolis", "Florianopolis"},
+		{"São Paulo", "Sao Paulo"},
+		{"Foz do Iguaçu", "Foz do Iguacu"},
+		{"Maceió, Alagoas", "Maceio, Alagoas"},
+		{"ÁÉÍÓÚ âêô ãõ à ü", "AEIOU aeo ao a u"},
+		{"São Luís", "Sao Luis"},
+		{"Peñíscola", "Peniscola"},
+		{"Cancún, México", "Cancun, Mexico"},
+		{"Bogotá", "Bogota"},
+		{"東京", "東京"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, RemoveAccents(tt.input))
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  SlugOptions
+		want  string
+	}{
+		{"Florianópolis", SlugOptions{}, "florianopolis"},
+		{"Hotel Fasano & Spa - São Paulo", SlugOptions{}, "hotel-fasano-e-spa-sao-paulo"},
+		{"  Pousada   d'Água  (Búzios) ", SlugOptions{}, "pousada-d-agua-buzios"},
+		{"Hotel Sol y Mar & Spa, Peñíscola", SlugOptions{Transliterations: SpanishTransliterations}, "hotel-sol-y-mar-y-spa-peniscola"},
+		{"Bed & Breakfast", SlugOptions{Transliterations: EnglishTransliterations}, "bed-and-breakfast"},
+		{"Straße Øresund", SlugOptions{}, "strasse-oresund"},
+		{"Rio de Janeiro 2016", SlugOptions{Separator: "_"}, "rio_de_janeiro_2016"},
+		{"Fernando de Noronha", SlugOptions{MaxLength: 14}, "fernando-de"},
+		{"Hotel Fasano & Spa - São Paulo", SlugOptions{MaxLength: 12}, "hotel-fasano"},
+		{"Arraial d'Ajuda", SlugOptions{MaxLength: 100}, "arraial-d-ajuda"},
+		{"!!!", SlugOptions{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, SlugifyWithOptions(tt.input, tt.opts))
+		})
+	}
+	assert.Equal(t, "sao-paulo", Slugify("São Paulo"))
+}
+
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		input  string
+		snake  string
+		kebab  string
+		camel  string
+		pascal string
+	}{
+		{"hotel name", "hotel_name", "hotel-name", "hotelName", "HotelName"},
+		{"HotelName", "hotel_name", "hotel-name", "hotelName", "HotelName"},
+		{"hotelID", "hotel_id", "hotel-id", "hotelId", "HotelId"},
+		{"HTTPServerPort8080", "http_server_port_8080", "http-server-port-8080", "httpServerPort8080", "HttpServerPort8080"},
+		{"check_in-date", "check_in_date", "check-in-date", "checkInDate", "CheckInDate"},
+		{"Preço Diária", "preço_diária", "preço-diária", "preçoDiária", "PreçoDiária"},
+		{"año Nuevo", "año_nuevo", "año-nuevo", "añoNuevo", "AñoNuevo"},
+		{"", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.snake, ToSnakeCase(tt.input))
+			assert.Equal(t, tt.kebab, ToKebabCase(tt.input))
+			assert.Equal(t, tt.camel, ToCamelCase(tt.input))
+			assert.Equal(t, tt.pascal, ToPascalCase(tt.input))
+		})
+	}
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	assert.Equal(t, "Hotel Copacabana Palace", CollapseWhitespace("  Hotel\n\tCopacabana    Palace \r\n"))
+	assert.Equal(t, "", CollapseWhitespace(" \n "))
+}
+
+func TestNormalizeSearchKey(t *testing.T) {
+	assert.Equal(t, "sao paulo", NormalizeSearchKey("  SÃO   Paulo "))
+	assert.Equal(t, "cancun mexico", NormalizeSearchKey("Cancún\nMéxico"))
+}
@@ -0,0 +1,193 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TruncateUnit defines how TruncateText measures the length of a text
+type TruncateUnit int
+
+const (
+	// TruncateRunes counts Unicode code points
+	TruncateRunes TruncateUnit = iota
+	// TruncateGraphemes counts user perceived characters, keeping combining
+	// marks, emoji modifiers and ZWJ sequences with their base character
+	TruncateGraphemes
+	// TruncateWidth counts display columns: wide East Asian characters and
+	// emoji take two columns, combining marks take none
+	TruncateWidth
+)
+
+// TruncateOptions configures TruncateText
+type TruncateOptions struct {
+	// Unit used to measure the limit
+	Unit TruncateUnit
+	// Ellipsis is appended to truncated texts and counted within the limit
+	Ellipsis string
+	// WordBoundary avoids cutting words in half when there is a whitespace
+	// before the limit
+	WordBoundary bool
+	// NormalizeWhitespace trims the text and collapses whitespace runs,
+	// including line breaks, into a single space before truncating
+	NormalizeWhitespace bool
+}
+
+// TruncateText shortens s to at most limit units, never splitting a multibyte
+// character:
+//
+//	TruncateText("Hotel São Conrado", 12, TruncateOptions{Ellipsis: "…", WordBoundary: true})
+//	// "Hotel São…"
+func TruncateText(s string, limit int, opts TruncateOptions) string {
+	if opts.NormalizeWhitespace {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if limit <= 0 {
+		return ""
+	}
+
+	clusters := textClusters(s, opts.Unit)
+	total := 0
+	for _, c := range clusters {
+		total += c.size
+	}
+	if total <= limit {
+		return s
+	}
+
+	ellipsisSize := 0
+	for _, c := range textClusters(opts.Ellipsis, opts.Unit) {
+		ellipsisSize += c.size
+	}
+	budget := limit - ellipsisSize
+	if budget <= 0 {
+		return TruncateText(opts.Ellipsis, limit, TruncateOptions{Unit: opts.Unit})
+	}
+
+	cut, used := 0, 0
+	taken := 0
+	for _, c := range clusters {
+		if used+c.size > budget {
+			break
+		}
+		used += c.size
+		cut = c.end
+		taken++
+	}
+
+	if opts.WordBoundary && taken < len(clusters) {
+		next, _ := utf8.DecodeRuneInString(s[cut:])
+		if !unicode.IsSpace(next) {
+			if i := strings.LastIndexFunc(s[:cut], unicode.IsSpace); i > 0 {
+				cut = i
+			}
+		}
+	}
+
+	if opts.WordBoundary || opts.Ellipsis != "" {
+		return strings.TrimRightFunc(s[:cut], unicode.IsSpace) + opts.Ellipsis
+	}
+	return s[:cut]
+}
+
+// TextLength measures s using the given unit
+func TextLength(s string, unit TruncateUnit) int {
+	length := 0
+	for _, c := range textClusters(s, unit) {
+		length += c.size
+	}
+	return length
+}
+
+type textCluster struct {
+	end  int
+	size int
+}
+
+func textClusters(s string, unit TruncateUnit) []textCluster {
+	clusters := make([]textCluster, 0, len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		width := runeWidth(r)
+
+		if unit != TruncateRunes {
+			end = graphemeEnd(s, r, end)
+		}
+
+		cluster := textCluster{end: end, size: 1}
+		if unit == TruncateWidth {
+			cluster.size = width
+		}
+		clusters = append(clusters, cluster)
+		i = end
+	}
+	return clusters
+}
+
+// graphemeEnd extends a cluster starting with base, whose first rune ends at
+// end, over the runes that attach to it
+func graphemeEnd(s string, base rune, end int) int {
+	regionalIndicators := 0
+	if isRegionalIndicator(base) {
+		regionalIndicators = 1
+	}
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		switch {
+		case isGraphemeExtend(r):
+			end += size
+		case r == '\u200d':
+			end += size
+			if end < len(s) {
+				_, next := utf8.DecodeRuneInString(s[end:])
+				end += next
+			}
+		case regionalIndicators == 1 && isRegionalIndicator(r):
+			regionalIndicators++
+			end += size
+		case base == '\r' && r == '\n':
+			end += size
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0xFE00 && r <= 0xFE0F) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) ||
+		(r >= 0xE0020 && r <= 0xE007F)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// runeWidth approximates the number of columns used to display r
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.IsControl(r) || isGraphemeExtend(r) || r == '\u200d':
+		return 0
+	case r >= 0x1100 && r <= 0x115F,
+		r >= 0x2E80 && r <= 0x303E,
+		r >= 0x3041 && r <= 0x33FF,
+		r >= 0x3400 && r <= 0x4DBF,
+		r >= 0x4E00 && r <= 0x9FFF,
+		r >= 0xA000 && r <= 0xA4CF,
+		r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF,
+		r >= 0xFE30 && r <= 0xFE4F,
+		r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F1E6 && r <= 0x1F1FF,
+		r >= 0x1F300 && r <= 0x1F64F,
+		r >= 0x1F900 && r <= 0x1FAFF,
+		r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	}
+	return 1
+}
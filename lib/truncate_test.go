@@ -0,0 +1,58 @@
+package lib
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		opts  TruncateOptions
+		want  string
+	}{
+		{"shorter than limit", "São Paulo", 20, TruncateOptions{}, "São Paulo"},
+		{"exact limit", "São Paulo", 9, TruncateOptions{}, "São Paulo"},
+		{"multibyte rune is not split", "São Paulo", 2, TruncateOptions{}, "Sã"},
+		{"ellipsis within limit", "Florianópolis", 8, TruncateOptions{Ellipsis: "..."}, "Flori..."},
+		{"unicode ellipsis", "Florianópolis", 8, TruncateOptions{Ellipsis: "…"}, "Florian…"},
+		{"word boundary", "Hotel São Conrado", 12, TruncateOptions{Ellipsis: "…", WordBoundary: true}, "Hotel São…"},
+		{"word boundary at space", "Hotel São Conrado", 10, TruncateOptions{WordBoundary: true}, "Hotel São"},
+		{"word boundary without space", "Pousada", 4, TruncateOptions{WordBoundary: true}, "Pous"},
+		{"ellipsis larger than limit", "Pousada", 2, TruncateOptions{Ellipsis: "..."}, ".."},
+		{"zero limit", "Pousada", 0, TruncateOptions{}, ""},
+		{"whitespace kept by default", "Hotel\n  Fasano", 8, TruncateOptions{}, "Hotel\n  "},
+		{"whitespace normalized", "  Hotel\n  Fasano ", 9, TruncateOptions{NormalizeWhitespace: true}, "Hotel Fas"},
+		{"combining accent split with runes unit", "Sa\u0303o", 2, TruncateOptions{}, "Sa"},
+		{"combining accent kept with graphemes unit", "Sa\u0303o Paulo", 2, TruncateOptions{Unit: TruncateGraphemes}, "Sa\u0303"},
+		{"emoji zwj sequence", "👨‍👩‍👧 family", 2, TruncateOptions{Unit: TruncateGraphemes}, "👨‍👩‍👧 "},
+		{"flag", "🇧🇷🇦🇷", 1, TruncateOptions{Unit: TruncateGraphemes}, "🇧🇷"},
+		{"display width", "東京タワー hotel", 5, TruncateOptions{Unit: TruncateWidth}, "東京"},
+		{"display width with ellipsis", "東京タワー hotel", 6, TruncateOptions{Unit: TruncateWidth, Ellipsis: "…"}, "東京…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateText(tt.s, tt.limit, tt.opts)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 9, TextLength("São Paulo", TruncateRunes))
+	assert.Equal(t, 4, TextLength("Sa\u0303o", TruncateRunes))
+	assert.Equal(t, 3, TextLength("Sa\u0303o", TruncateGraphemes))
+	assert.Equal(t, 3, TextLength("Sa\u0303o", TruncateWidth))
+	assert.Equal(t, 10, TextLength("東京タワー", TruncateWidth))
+	assert.Equal(t, 2, TextLength("👍🏽", TruncateWidth))
+}
+
+func TestTruncateDoesNotSplitMultibyteCharacters(t *testing.T) {
+	assert.Equal(t, "Sã", Truncate("São Paulo", 2))
+	assert.True(t, utf8.ValidString(Truncate("Florianópolis", 8)))
+}
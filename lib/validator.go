@@ -0,0 +1,454 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// Languages supported by the built-in validation messages
+const (
+	LanguageEnglish      = "en"
+	LanguagePortugueseBR = "pt-BR"
+)
+
+const validateTag = "validate"
+
+var regexpEmail = regexp.MustCompile(
+	`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+// ValidationRule reports whether value satisfies the rule with the given
+// tag parameter, e.g. "3" for `min=3`. Pointers are already dereferenced
+type ValidationRule func(value reflect.Value, param string) bool
+
+// Validator checks structs against rules declared in the `validate` tag:
+//
+//	type Booking struct {
+//		Email   string   `json:"email" validate:"required,email"`
+//		Checkin string   `json:"checkin" validate:"required,date=YYYY-MM-DD"`
+//		Adults  int      `json:"adults" validate:"min=1,max=8"`
+//		Rooms   []string `json:"rooms" validate:"min=1,dive,oneof=single double"`
+//	}
+//
+// Rules are comma separated. `omitempty` skips the remaining rules for zero
+// values and `dive` applies the remaining rules to each slice or map item.
+// Nested structs are validated recursively
+type Validator struct {
+	// Language of the messages returned by Validate
+	Language string
+
+	mutex    sync.RWMutex
+	rules    map[string]ValidationRule
+	messages map[string]map[string]string
+	cache    sync.Map
+}
+
+// DefaultValidator is used by Validate and RegisterValidationRule
+var DefaultValidator = NewValidator()
+
+// NewValidator creates a Validator with the built-in rules and messages
+func NewValidator() *Validator {
+	v := &Validator{
+		Language: LanguageEnglish,
+		rules: map[string]ValidationRule{
+			"required": validateRequired,
+			"min":      validateMin,
+			"max":      validateMax,
+			"oneof":    validateOneOf,
+			"date":     validateDate,
+			"email":    validateEmail,
+			"cpf":      validateCPF,
+			"cnpj":     validateCNPJ,
+			"cep":      validateCEP,
+			"phone":    validatePhone,
+		},
+		messages: map[string]map[string]string{},
+	}
+	for lang, messages := range defaultValidationMessages {
+		v.messages[lang] = map[string]string{}
+		for code, message := range messages {
+			v.messages[lang][code] = message
+		}
+	}
+	return v
+}
+
+var defaultValidationMessages = map[string]map[string]string{
+	LanguageEnglish: {
+		"required":   "is required",
+		"min":        "must be at least {param}",
+		"max":        "must be at most {param}",
+		"min_length": "must have a length of at least {param}",
+		"max_length": "must have a length of at most {param}",
+		"oneof":      "must be one of: {param}",
+		"date":       "must be a date in the {param} format",
+		"email":      "must be a valid e-mail",
+		"cpf":        "must be a valid CPF",
+		"cnpj":       "must be a valid CNPJ",
+		"cep":        "must be a valid CEP",
+		"phone":      "must be a valid phone number",
+		"invalid":    "is invalid",
+	},
+	LanguagePortugueseBR: {
+		"required":   "é obrigatório",
+		"min":        "deve ser no mínimo {param}",
+		"max":        "deve ser no máximo {param}",
+		"min_length": "deve ter tamanho mínimo {param}",
+		"max_length": "deve ter tamanho máximo {param}",
+		"oneof":      "deve ser um dos valores: {param}",
+		"date":       "deve ser uma data no formato {param}",
+		"email":      "deve ser um e-mail válido",
+		"cpf":        "deve ser um CPF válido",
+		"cnpj":       "deve ser um CNPJ válido",
+		"cep":        "deve ser um CEP válido",
+		"phone":      "deve ser um telefone válido",
+		"invalid":    "é inválido",
+	},
+}
+
+// Validate checks v using DefaultValidator
+func Validate(v interface{}) error {
+	return DefaultValidator.Validate(v)
+}
+
+// RegisterValidationRule registers a custom rule in DefaultValidator
+func RegisterValidationRule(name string, rule ValidationRule, messages map[string]string) {
+	DefaultValidator.RegisterRule(name, rule, messages)
+}
+
+// TranslateFieldErrors rewrites the messages of fe in the given language
+// using DefaultValidator
+func TranslateFieldErrors(fe FieldErrors, lang string) FieldErrors {
+	return DefaultValidator.Translate(fe, lang)
+}
+
+// RegisterRule registers a custom rule. messages maps a language to the
+// message template of the rule, where {param} is replaced by the tag parameter
+func (v *Validator) RegisterRule(name string, rule ValidationRule, messages map[string]string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.rules[name] = rule
+	for lang, message := range messages {
+		if v.messages[lang] == nil {
+			v.messages[lang] = map[string]string{}
+		}
+		v.messages[lang][name] = message
+	}
+}
+
+// Translate returns a copy of fe with messages in the given language.
+// Unknown languages fall back to English
+func (v *Validator) Translate(fe FieldErrors, lang string) FieldErrors {
+	translated := make(FieldErrors, len(fe))
+	for i, f := range fe {
+		f.Message = v.message(lang, f.Code, f.Param)
+		translated[i] = f
+	}
+	return translated
+}
+
+func (v *Validator) message(lang, code, param string) string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	template, ok := v.messages[lang][code]
+	if !ok {
+		template, ok = v.messages[LanguageEnglish][code]
+	}
+	if !ok {
+		template = v.messages[LanguageEnglish]["invalid"]
+	}
+	return strings.Replace(template, "{param}", param, -1)
+}
+
+// Validate checks every field of the struct pointed by or held in s and
+// returns FieldErrors, or nil when all rules pass
+func (v *Validator) Validate(s interface{}) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return errors.New("Validate: nil value")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return errors.Errorf("Validate: expected struct, got %T", s)
+	}
+
+	fieldErrors, err := v.validateStruct(value, "")
+	if err != nil {
+		return err
+	}
+	if len(fieldErrors) > 0 {
+		return v.Translate(fieldErrors, v.Language)
+	}
+	return nil
+}
+
+type validationRuleCall struct {
+	name  string
+	param string
+}
+
+type validationField struct {
+	index int
+	name  string
+	rules []validationRuleCall
+}
+
+func (v *Validator) fields(t reflect.Type) []validationField {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]validationField)
+	}
+
+	var fields []validationField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get(validateTag)
+		if tag == "-" {
+			continue
+		}
+		name := field.Name
+		if jsonName := strings.Split(field.Tag.Get("json"), ",")[0]; jsonName != "" && jsonName != "-" {
+			name = jsonName
+		}
+		fields = append(fields, validationField{index: i, name: name, rules: parseValidationTag(tag)})
+	}
+
+	v.cache.Store(t, fields)
+	return fields
+}
+
+func parseValidationTag(tag string) (rules []validationRuleCall) {
+	if tag == "" {
+		return nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		rule := validationRuleCall{name: part}
+		if i := strings.Index(part, "="); i >= 0 {
+			rule.name, rule.param = part[:i], part[i+1:]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (v *Validator) validateStruct(value reflect.Value, prefix string) (FieldErrors, error) {
+	var fieldErrors FieldErrors
+	for _, field := range v.fields(value.Type()) {
+		errs, err := v.validateValue(value.Field(field.index), prefix+field.name, field.rules)
+		if err != nil {
+			return nil, err
+		}
+		fieldErrors = append(fieldErrors, errs...)
+	}
+	return fieldErrors, nil
+}
+
+func (v *Validator) validateValue(value reflect.Value, path string, rules []validationRuleCall) (FieldErrors, error) {
+	for i, rule := range rules {
+		switch rule.name {
+		case "omitempty":
+			if isZeroValue(value) {
+				return nil, nil
+			}
+			continue
+		case "dive":
+			return v.dive(value, path, rules[i+1:])
+		}
+
+		if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			// required only rejects nil pointers, so that they can point to
+			// zero values checked by the other rules
+			if rule.name == "required" {
+				if value.IsNil() {
+					return FieldErrors{{Field: path, Code: rule.name}}, nil
+				}
+				continue
+			}
+			if value.IsNil() {
+				continue
+			}
+		}
+
+		v.mutex.RLock()
+		fn, ok := v.rules[rule.name]
+		v.mutex.RUnlock()
+		if !ok {
+			return nil, errors.Errorf("Validate: unknown rule %q on %s", rule.name, path)
+		}
+
+		if !fn(IndirectValue(value), rule.param) {
+			return FieldErrors{{
+				Field: path,
+				Code:  validationCode(rule.name, IndirectValue(value)),
+				Param: rule.param,
+			}}, nil
+		}
+	}
+
+	nested := IndirectValue(value)
+	if nested.IsValid() && nested.Kind() == reflect.Struct && nested.Type() != timeType {
+		return v.validateStruct(nested, path+".")
+	}
+	return nil, nil
+}
+
+func (v *Validator) dive(value reflect.Value, path string, rules []validationRuleCall) (FieldErrors, error) {
+	value = IndirectValue(value)
+	var fieldErrors FieldErrors
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			errs, err := v.validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), rules)
+			if err != nil {
+				return nil, err
+			}
+			fieldErrors = append(fieldErrors, errs...)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sortedKeys := make([]string, len(keys))
+		byKey := map[string]reflect.Value{}
+		for i, key := range keys {
+			sortedKeys[i] = cast.ToString(key.Interface())
+			byKey[sortedKeys[i]] = key
+		}
+		sort.Strings(sortedKeys)
+		for _, key := range sortedKeys {
+			errs, err := v.validateValue(value.MapIndex(byKey[key]), fmt.Sprintf("%s[%s]", path, key), rules)
+			if err != nil {
+				return nil, err
+			}
+			fieldErrors = append(fieldErrors, errs...)
+		}
+	default:
+		if value.IsValid() {
+			return nil, errors.Errorf("Validate: dive on %s requires a slice or map", path)
+		}
+	}
+	return fieldErrors, nil
+}
+
+func validationCode(rule string, value reflect.Value) string {
+	if rule != "min" && rule != "max" {
+		return rule
+	}
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rule + "_length"
+	}
+	return rule
+}
+
+func validateRequired(value reflect.Value, _ string) bool {
+	return !isZeroValue(value)
+}
+
+func validateMin(value reflect.Value, param string) bool {
+	limit, size, ok := validationSize(value, param)
+	return !ok || size >= limit
+}
+
+func validateMax(value reflect.Value, param string) bool {
+	limit, size, ok := validationSize(value, param)
+	return !ok || size <= limit
+}
+
+// validationSize returns the number compared by min and max: the value of
+// numbers, the amount of characters of strings and the length of lists
+func validationSize(value reflect.Value, param string) (limit, size float64, ok bool) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil || !value.IsValid() {
+		return 0, 0, false
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return limit, float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return limit, float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return limit, value.Float(), true
+	case reflect.String:
+		return limit, float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return limit, float64(value.Len()), true
+	}
+	return 0, 0, false
+}
+
+func validateOneOf(value reflect.Value, param string) bool {
+	if !value.IsValid() {
+		return true
+	}
+	s := cast.ToString(value.Interface())
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDate checks strings against a layout written with YYYY, MM, DD, HH,
+// mm and ss. Without layout any format accepted by ParseDateStringToTime passes
+func validateDate(value reflect.Value, param string) bool {
+	if !value.IsValid() || value.Type() == timeType {
+		return true
+	}
+	if value.Kind() != reflect.String {
+		return false
+	}
+	if param == "" {
+		_, err := ParseDateStringToTime(value.String())
+		return err == nil
+	}
+	_, err := time.Parse(dateLayoutReplacer.Replace(param), value.String())
+	return err == nil
+}
+
+var dateLayoutReplacer = strings.NewReplacer(
+	"YYYY", "2006",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+func validateEmail(value reflect.Value, _ string) bool {
+	return value.Kind() == reflect.String && regexpEmail.MatchString(value.String())
+}
+
+func validateCPF(value reflect.Value, _ string) bool {
+	return value.Kind() == reflect.String && IsValidCPF(value.String())
+}
+
+func validateCNPJ(value reflect.Value, _ string) bool {
+	return value.Kind() == reflect.String && IsValidCNPJ(value.String())
+}
+
+func validateCEP(value reflect.Value, _ string) bool {
+	return value.Kind() == reflect.String && IsValidCEP(value.String())
+}
+
+func validatePhone(value reflect.Value, _ string) bool {
+	return value.Kind() == reflect.String && IsValidPhone(value.String())
+}
@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validationGuest struct {
+	Name     string  `json:"name" validate:"required,min=2"`
+	Document string  `json:"document" validate:"omitempty,cpf"`
+	Company  *string `json:"company" validate:"omitempty,cnpj"`
+}
+
+type validationBooking struct {
+	Email    string            `json:"email" validate:"required,email"`
+	Checkin  string            `json:"checkin" validate:"required,date=YYYY-MM-DD"`
+	Adults   int               `json:"adults" validate:"min=1,max=8"`
+	Board    string            `json:"board" validate:"oneof=RO BB HB FB"`
+	Rooms    []string          `json:"rooms" validate:"min=1,dive,oneof=single double"`
+	Guests   []validationGuest `json:"guests" validate:"dive"`
+	Owner    *validationGuest  `json:"owner" validate:"required"`
+	Notes    string
+	internal string
+}
+
+func validBooking() validationBooking {
+	company := "11.222.333/0001-81"
+	return validationBooking{
+		Email:   "guest@hurb.com",
+		Checkin: "2020-10-22",
+		Adults:  2,
+		Board:   "BB",
+		Rooms:   []string{"double"},
+		Guests:  []validationGuest{{Name: "José", Document: "529.982.247-25"}},
+		Owner:   &validationGuest{Name: "Maria", Company: &company},
+	}
+}
+
+func TestValidateValidStruct(t *testing.T) {
+	booking := validBooking()
+	assert.Nil(t, Validate(booking))
+	assert.Nil(t, Validate(&booking))
+}
+
+func TestValidateReportsFieldErrors(t *testing.T) {
+	booking := validBooking()
+	booking.Email = "guest@"
+	booking.Checkin = "22/10/2020"
+	booking.Adults = 9
+	booking.Board = "AI"
+	booking.Rooms = []string{"double", "suite"}
+	booking.Guests = append(booking.Guests, validationGuest{Name: "A", Document: "111.111.111-11"})
+	invalid := "11.222.333/0001-80"
+	booking.Owner.Company = &invalid
+
+	err := Validate(booking)
+
+	assert.Equal(t, FieldErrors{
+		{Field: "email", Code: "email", Message: "must be a valid e-mail"},
+		{Field: "checkin", Code: "date", Message: "must be a date in the YYYY-MM-DD format", Param: "YYYY-MM-DD"},
+		{Field: "adults", Code: "max", Message: "must be at most 8", Param: "8"},
+		{Field: "board", Code: "oneof", Message: "must be one of: RO BB HB FB", Param: "RO BB HB FB"},
+		{Field: "rooms[1]", Code: "oneof", Message: "must be one of: single double", Param: "single double"},
+		{Field: "guests[1].name", Code: "min_length", Message: "must have a length of at least 2", Param: "2"},
+		{Field: "guests[1].document", Code: "cpf", Message: "must be a valid CPF"},
+		{Field: "owner.company", Code: "cnpj", Message: "must be a valid CNPJ"},
+	}, err)
+}
+
+func TestValidateRequired(t *testing.T) {
+	err := Validate(validationBooking{})
+
+	fieldErrors, ok := err.(FieldErrors)
+	assert.True(t, ok)
+
+	var required []string
+	for _, f := range fieldErrors {
+		if f.Code == "required" {
+			required = append(required, f.Field)
+		}
+	}
+	assert.Equal(t, []string{"email", "checkin", "owner"}, required)
+}
+
+func TestValidateRequiredPointers(t *testing.T) {
+	type discount struct {
+		Percent *int `json:"percent" validate:"required,min=1"`
+	}
+	zero, ten := 0, 10
+
+	assert.NoError(t, Validate(&discount{Percent: &ten}))
+	assert.Equal(t, FieldErrors{{Field: "percent", Code: "required", Message: "is required"}}, Validate(&discount{}))
+	assert.Equal(t, FieldErrors{{Field: "percent", Code: "min", Message: "must be at least 1", Param: "1"}}, Validate(&discount{Percent: &zero}))
+
+	booking := validBooking()
+	booking.Owner = &validationGuest{}
+	assert.Equal(t, FieldErrors{{Field: "owner.name", Code: "required", Message: "is required"}}, Validate(booking))
+}
+
+func TestValidateTranslation(t *testing.T) {
+	booking := validBooking()
+	booking.Email = ""
+	booking.Adults = 0
+
+	err := Validate(booking)
+	translated := TranslateFieldErrors(err.(FieldErrors), LanguagePortugueseBR)
+
+	assert.Equal(t, "é obrigatório", translated[0].Message)
+	assert.Equal(t, "deve ser no mínimo 1", translated[1].Message)
+	assert.Equal(t, "is required", err.(FieldErrors)[0].Message, "translation must not change the original")
+
+	validator := NewValidator()
+	validator.Language = LanguagePortugueseBR
+	err = validator.Validate(booking)
+	assert.Equal(t, "é obrigatório", err.(FieldErrors)[0].Message)
+}
+
+func TestValidateCustomRule(t *testing.T) {
+	validator := NewValidator()
+	validator.RegisterRule("prefix", func(value reflect.Value, param string) bool {
+		return strings.HasPrefix(value.String(), param)
+	}, map[string]string{
+		LanguageEnglish:      "must start with {param}",
+		LanguagePortugueseBR: "deve começar com {param}",
+	})
+
+	s := struct {
+		Code string `json:"code" validate:"prefix=HU"`
+	}{Code: "XX-123"}
+
+	err := validator.Validate(s)
+	assert.Equal(t, FieldErrors{{Field: "code", Code: "prefix", Message: "must start with HU", Param: "HU"}}, err)
+	assert.Equal(t, "deve começar com HU", validator.Translate(err.(FieldErrors), LanguagePortugueseBR)[0].Message)
+
+	s.Code = "HU-123"
+	assert.Nil(t, validator.Validate(s))
+}
+
+func TestValidateInvalidInput(t *testing.T) {
+	assert.NotNil(t, Validate(nil))
+	assert.NotNil(t, Validate("foo"))
+
+	var nilBooking *validationBooking
+	assert.NotNil(t, Validate(nilBooking))
+
+	unknown := struct {
+		Code string `validate:"unknown"`
+	}{}
+	assert.NotNil(t, Validate(unknown))
+}